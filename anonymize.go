@@ -0,0 +1,90 @@
+package subnetmath
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// AnonymizerKeyLen is the length of the key of an Anonymizer
+const AnonymizerKeyLen = 32
+
+// ErrInvalidKeyLength is wrapped by the *ValidationError of NewAnonymizer
+var ErrInvalidKeyLength = errors.New("invalid key length")
+
+// Anonymizer maps addresses to anonymized addresses with the prefix-preserving Crypto-PAn
+// scheme, so two addresses that share their first k bits are mapped to addresses that also
+// share their first k bits. The mapping is a bijection within each address family that only
+// depends on the key. An Anonymizer is safe for concurrent use.
+type Anonymizer struct {
+	block cipher.Block
+	pad   [aes.BlockSize]byte
+}
+
+// NewAnonymizer returns an Anonymizer for the 32 byte secret key. The first half of the key
+// is the AES key and the second half is encrypted to form the pad as in Crypto-PAn, so
+// IPv4 results match other Crypto-PAn implementations given the same key.
+func NewAnonymizer(key []byte) (*Anonymizer, error) {
+	if len(key) != AnonymizerKeyLen {
+		return nil, &ValidationError{"key", fmt.Errorf("%w: %d bytes rather than %d", ErrInvalidKeyLength, len(key), AnonymizerKeyLen)}
+	}
+	block, err := aes.NewCipher(key[:aes.BlockSize])
+	if err != nil {
+		return nil, err
+	}
+	a := &Anonymizer{block: block}
+	block.Encrypt(a.pad[:], key[aes.BlockSize:])
+	return a, nil
+}
+
+// Addr returns the anonymized form of the address in 16 bytes. IPv4 addresses, including
+// IPv4-mapped addresses, are anonymized as IPv4. Nil is returned if the address is invalid.
+func (a *Anonymizer) Addr(address net.IP) net.IP {
+	addr, family := uint128FromAddr(address)
+	if family == 0 {
+		return nil
+	}
+	return uint128Addr(a.anonymize(addr, family), family)
+}
+
+// Network returns the network containing the anonymized form of the address of the network
+// with the same prefix length. As the anonymization preserves prefixes, one network contains
+// another exactly when their anonymized forms do, so the results of functions such as
+// FindUnusedSubnets over anonymized networks are the anonymized results over the originals.
+// Nil is returned if the network isn't a valid CIDR network.
+func (a *Anonymizer) Network(network *net.IPNet) *net.IPNet {
+	first, _, family := uint128Range(network)
+	if family == 0 || !MaskIsContiguous(network.Mask) {
+		return nil
+	}
+	ones, bits := familyPrefixLength(network)
+	anonymized := a.anonymize(first, family).and(uint128Ones(bits - ones).not())
+	return appendNetworkPointer(nil, anonymized, ones, family)[0]
+}
+
+// anonymize flips each bit of the address according to the most significant bit of the
+// encryption of the bits before it followed by the remaining bits of the pad
+func (a *Anonymizer) anonymize(addr uint128, family Family) uint128 {
+	bits := familyBits(family)
+	pad := uint128FromBytes(a.pad[:])
+	// IPv4 addresses take the place of the first 32 bits of the pad
+	padBits := pad
+	if family == IPv4 {
+		padBits = uint128{0, pad.hi >> 32}
+	}
+	var input, output [aes.BlockSize]byte
+	flips := uint128{}
+	for position := 0; position < bits; position++ {
+		prefixMask := uint128Ones(bits).and(uint128Ones(bits - position).not())
+		value := addr.and(prefixMask).or(padBits.and(prefixMask.not()))
+		if family == IPv4 {
+			value = uint128{value.lo<<32 | pad.hi&0xffffffff, pad.lo}
+		}
+		value.putAddr(input[:])
+		a.block.Encrypt(output[:], input[:])
+		flips = flips.or(uint128{0, uint64(output[0] >> 7)}.lsh(bits - 1 - position))
+	}
+	return uint128{addr.hi ^ flips.hi, addr.lo ^ flips.lo}
+}
@@ -0,0 +1,128 @@
+package subnetmath
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"slices"
+	"testing"
+)
+
+// cryptoPAnKey is the key of the sample trace distributed with the reference Crypto-PAn implementation
+var cryptoPAnKey = []byte{
+	21, 34, 23, 141, 51, 164, 207, 128, 19, 10, 91, 22, 73, 144, 125, 16,
+	216, 152, 143, 131, 121, 121, 101, 39, 98, 87, 76, 45, 42, 132, 34, 2,
+}
+
+func TestAnonymizerAddr(t *testing.T) {
+	anonymizer, err := NewAnonymizer(cryptoPAnKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, expected := range map[string]string{
+		"128.11.68.132":   "135.242.180.132",
+		"129.118.74.4":    "134.136.186.123",
+		"130.132.252.244": "133.68.164.234",
+		"141.223.7.43":    "141.167.8.160",
+		"141.233.145.108": "141.129.237.235",
+		"152.163.225.39":  "151.140.114.167",
+		"156.29.3.236":    "147.225.12.42",
+		"165.247.96.84":   "162.9.99.234",
+		"166.107.77.190":  "160.132.178.185",
+		"192.102.249.13":  "252.138.62.131",
+	} {
+		output := anonymizer.Addr(net.ParseIP(input))
+		if output.String() != expected || len(output) != net.IPv6len {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
+
+func TestAnonymizerPreservesPrefixes(t *testing.T) {
+	anonymizer, _ := NewAnonymizer(cryptoPAnKey)
+	commonPrefix := func(a, b net.IP) int {
+		x, _ := uint128FromAddr(a)
+		y, _ := uint128FromAddr(b)
+		return 128 - uint128{x.hi ^ y.hi, x.lo ^ y.lo}.bitLen()
+	}
+	src := rand.NewSource(1)
+	for _, aggregate := range []string{"2001:db8::/32", "::/0", "10.0.0.0/8"} {
+		network := ParseNetworkCIDR(aggregate)
+		seen := map[string]bool{}
+		for i := 0; i < 200; i++ {
+			a, b := RandomAddr(network, src), RandomAddr(network, src)
+			anonymizedA, anonymizedB := anonymizer.Addr(a), anonymizer.Addr(b)
+			seen[anonymizedA.String()] = true
+			if commonPrefix(a, b) != commonPrefix(anonymizedA, anonymizedB) ||
+				AddrFamily(a) != AddrFamily(anonymizedA) || !anonymizedA.Equal(anonymizer.Addr(a)) {
+				t.Fatal(
+					"\n<<<input>>>\n", a, b,
+					"\n<<<actual_output>>>\n", anonymizedA, anonymizedB,
+					"\n<<<expected_output>>>\n", "a shared prefix of", commonPrefix(a, b),
+				)
+			}
+		}
+		if len(seen) != 200 {
+			t.Error(
+				"\n<<<input>>>\n", aggregate,
+				"\n<<<actual_output>>>\n", len(seen),
+				"\n<<<expected_output>>>\n", 200,
+			)
+		}
+	}
+	mapped, ipv4 := anonymizer.Addr(net.ParseIP("::ffff:128.11.68.132")), anonymizer.Addr(net.IP{128, 11, 68, 132})
+	if !mapped.Equal(ipv4) || anonymizer.Addr(net.IP{1, 2, 3}) != nil {
+		t.Error(
+			"\n<<<input>>>\n", "::ffff:128.11.68.132",
+			"\n<<<actual_output>>>\n", mapped,
+			"\n<<<expected_output>>>\n", ipv4,
+		)
+	}
+}
+
+func TestAnonymizerNetwork(t *testing.T) {
+	anonymizer, _ := NewAnonymizer(cryptoPAnKey)
+	aggregate := ParseNetworkCIDR("2001:db8::/32")
+	used := []*net.IPNet{ParseNetworkCIDR("2001:db8:1::/48"), ParseNetworkCIDR("2001:db8:ff00::/40")}
+	anonymizedUsed := make([]*net.IPNet, len(used))
+	for i, network := range used {
+		anonymizedUsed[i] = anonymizer.Network(network)
+	}
+	var expected []*net.IPNet
+	for _, network := range FindUnusedSubnets(aggregate, used...) {
+		expected = append(expected, anonymizer.Network(network))
+	}
+	output := FindUnusedSubnets(anonymizer.Network(aggregate), anonymizedUsed...)
+	slices.SortFunc(expected, networkOrder)
+	if !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, used,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	host := anonymizer.Network(ParseNetworkCIDR("128.11.68.132/32"))
+	if host.String() != "135.242.180.132/32" || anonymizer.Network(nil) != nil {
+		t.Error(
+			"\n<<<input>>>\n", "128.11.68.132/32",
+			"\n<<<actual_output>>>\n", host,
+			"\n<<<expected_output>>>\n", "135.242.180.132/32",
+		)
+	}
+}
+
+func TestNewAnonymizerKeyLength(t *testing.T) {
+	_, err := NewAnonymizer(cryptoPAnKey[:16])
+	var validationErr *ValidationError
+	if !errors.Is(err, ErrInvalidKeyLength) || !errors.As(err, &validationErr) {
+		t.Error(
+			"\n<<<input>>>\n", cryptoPAnKey[:16],
+			"\n<<<actual_output>>>\n", err,
+			"\n<<<expected_output>>>\n", ErrInvalidKeyLength,
+		)
+	}
+}
@@ -0,0 +1,199 @@
+package subnetmath
+
+import (
+	"net"
+	"slices"
+	"sync"
+)
+
+// AppendInbetweenSubnets appends the subnets of FindInbetweenSubnets to dst and returns the
+// extended slice. Subnets already held in the spare capacity of dst are overwritten and reused
+// along with the memory of their IP and Mask, so calls that pass dst[:0] from a previous call
+// make no heap allocations once the results fit.
+func AppendInbetweenSubnets(dst []*net.IPNet, start, stop net.IP) []*net.IPNet {
+	walkInbetweenSubnets(start, stop, func(addr uint128, ones int, family Family) bool {
+		dst = appendNetworkPointer(dst, addr, ones, family)
+		return true
+	})
+	return dst
+}
+
+// AppendInbetweenSubnetValues is the value typed equivalent of AppendInbetweenSubnets
+func AppendInbetweenSubnetValues(dst []net.IPNet, start, stop net.IP) []net.IPNet {
+	walkInbetweenSubnets(start, stop, func(addr uint128, ones int, family Family) bool {
+		dst = appendNetworkValue(dst, addr, ones, family)
+		return true
+	})
+	return dst
+}
+
+// AppendUnusedSubnets appends the subnets of FindUnusedSubnets to dst and returns the
+// extended slice. Subnets already held in the spare capacity of dst are overwritten and reused
+// along with the memory of their IP and Mask. Subnets of a different family than the
+// aggregate are ignored.
+func AppendUnusedSubnets(dst []*net.IPNet, aggregate *net.IPNet, subnets ...*net.IPNet) []*net.IPNet {
+	walkUnusedSubnets(aggregate, subnets, func(addr uint128, ones int, family Family) bool {
+		dst = appendNetworkPointer(dst, addr, ones, family)
+		return true
+	})
+	return dst
+}
+
+// AppendUnusedSubnetValues is the value typed equivalent of AppendUnusedSubnets
+func AppendUnusedSubnetValues(dst []net.IPNet, aggregate *net.IPNet, subnets ...*net.IPNet) []net.IPNet {
+	walkUnusedSubnets(aggregate, subnets, func(addr uint128, ones int, family Family) bool {
+		dst = appendNetworkValue(dst, addr, ones, family)
+		return true
+	})
+	return dst
+}
+
+// subnetEmitter receives each subnet found by a walk and returns false to stop walking
+type subnetEmitter func(addr uint128, ones int, family Family) bool
+
+// walkInbetweenSubnets emits the same subnets as FindInbetweenSubnets and returns false
+// if the walk was stopped early
+func walkInbetweenSubnets(start, stop net.IP, emit subnetEmitter) bool {
+	first, family := uint128FromAddr(start)
+	last, stopFamily := uint128FromAddr(stop)
+	if family == 0 || family != stopFamily || first.cmp(last) >= 0 {
+		return true
+	}
+	return decomposeRange(first, last, family, 1, func(addr uint128, ones int) bool {
+		return emit(addr, ones, family)
+	})
+}
+
+type addrRange struct {
+	first, last uint128
+}
+
+// rangePool holds the sorted copies of sibling subnets used by walkUnusedSubnets
+var rangePool = sync.Pool{
+	New: func() interface{} {
+		return new([]addrRange)
+	},
+}
+
+// cancelCheckInterval is the number of siblings collected or compared between checks of
+// whether a walk should stop
+const cancelCheckInterval = 1024
+
+// walkUnusedSubnets emits the same subnets as FindUnusedSubnets and returns false
+// if the walk was stopped early. The siblings are sorted and each gap between them
+// is decomposed into subnets.
+func walkUnusedSubnets(aggregate *net.IPNet, subnets []*net.IPNet, emit subnetEmitter) bool {
+	return walkUnusedSubnetsUntil(aggregate, subnets, nil, emit)
+}
+
+// walkUnusedSubnetsUntil is walkUnusedSubnets that also stops early once done returns true.
+// Done is checked periodically while the siblings are collected and sorted so that a large
+// number of siblings doesn't delay stopping.
+func walkUnusedSubnetsUntil(aggregate *net.IPNet, subnets []*net.IPNet, done func() bool, emit subnetEmitter) bool {
+	aggregateFirst, aggregateLast, family := uint128Range(aggregate)
+	if family == 0 {
+		return true
+	}
+	checks, stopped := 0, false
+	shouldStop := func() bool {
+		if done != nil && !stopped {
+			if checks++; checks%cancelCheckInterval == 0 {
+				stopped = done()
+			}
+		}
+		return stopped
+	}
+	pooled := rangePool.Get().(*[]addrRange)
+	defer rangePool.Put(pooled)
+	ranges := (*pooled)[:0]
+	for _, subnet := range subnets {
+		if shouldStop() {
+			return false
+		}
+		first, last, subnetFamily := uint128Range(subnet)
+		if subnetFamily == family && last.cmp(aggregateFirst) >= 0 && first.cmp(aggregateLast) <= 0 {
+			ranges = append(ranges, addrRange{first, last})
+		}
+	}
+	*pooled = ranges
+	// once stopped every comparison is equal so the sort finishes without further work
+	slices.SortFunc(ranges, func(a, b addrRange) int {
+		if shouldStop() {
+			return 0
+		}
+		return a.first.cmp(b.first)
+	})
+	if stopped {
+		return false
+	}
+	emitFamily := func(addr uint128, ones int) bool {
+		return emit(addr, ones, family)
+	}
+	cursor := aggregateFirst
+	for _, used := range ranges {
+		if used.first.cmp(cursor) > 0 {
+			if !decomposeRange(cursor, used.first.sub(uint128{0, 1}), family, 0, emitFamily) {
+				return false
+			}
+		}
+		if used.last.cmp(aggregateLast) >= 0 {
+			return true
+		}
+		if used.last.cmp(cursor) >= 0 {
+			cursor, _ = used.last.add(uint128{0, 1})
+		}
+	}
+	return decomposeRange(cursor, aggregateLast, family, 0, emitFamily)
+}
+
+func appendNetworkPointer(dst []*net.IPNet, addr uint128, ones int, family Family) []*net.IPNet {
+	if len(dst) < cap(dst) {
+		dst = dst[:len(dst)+1]
+	} else {
+		dst = append(dst, nil)
+	}
+	if dst[len(dst)-1] == nil {
+		dst[len(dst)-1] = new(net.IPNet)
+	}
+	setNetwork(dst[len(dst)-1], addr, ones, family)
+	return dst
+}
+
+func appendNetworkValue(dst []net.IPNet, addr uint128, ones int, family Family) []net.IPNet {
+	if len(dst) < cap(dst) {
+		dst = dst[:len(dst)+1]
+	} else {
+		dst = append(dst, net.IPNet{})
+	}
+	setNetwork(&dst[len(dst)-1], addr, ones, family)
+	return dst
+}
+
+// setNetwork overwrites the network in its canonical form reusing the memory of its IP and Mask
+func setNetwork(network *net.IPNet, addr uint128, ones int, family Family) {
+	length := net.IPv4len
+	if family == IPv6 {
+		length = net.IPv6len
+	}
+	if cap(network.IP) >= length {
+		network.IP = network.IP[:length]
+	} else {
+		network.IP = make(net.IP, length)
+	}
+	if cap(network.Mask) >= length {
+		network.Mask = network.Mask[:length]
+	} else {
+		network.Mask = make(net.IPMask, length)
+	}
+	addr.putAddr(network.IP)
+	for i := range network.Mask {
+		switch {
+		case ones >= 8:
+			network.Mask[i] = 0xff
+			ones -= 8
+		default:
+			network.Mask[i] = ^byte(0xff >> uint(ones))
+			ones = 0
+		}
+	}
+}
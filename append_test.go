@@ -0,0 +1,123 @@
+package subnetmath
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAppendInbetweenSubnets(t *testing.T) {
+	inputs := [][]net.IP{
+		{net.ParseIP("192.168.1.2"), net.ParseIP("192.168.2.2")},
+		{net.ParseIP("0.0.0.0"), net.ParseIP("255.255.255.255")},
+		{net.ParseIP("2001:400::"), net.ParseIP("2001:440:ffff:ffff:7fff:ffff:ffff:ffff")},
+		{net.ParseIP("::"), net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")},
+		{net.ParseIP("::1"), net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe")},
+		{net.ParseIP("192.168.1.2"), net.ParseIP("192.168.1.2")},
+		{net.ParseIP("192.168.1.2"), net.ParseIP("2001:db8::")},
+	}
+	var reused []*net.IPNet
+	for _, input := range inputs {
+		expected := FindInbetweenSubnets(input[0], input[1])
+		reused = AppendInbetweenSubnets(reused[:0], input[0], input[1])
+		values := AppendInbetweenSubnetValues(nil, input[0], input[1])
+		if !sliceOfSubnetsAreEqual(reused, expected) || len(values) != len(expected) {
+			t.Error("\n",
+				"<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", reused,
+				"\n<<<expected_output>>>\n", expected,
+			)
+			continue
+		}
+		for i := range values {
+			if len(values[i].IP) != len(values[i].Mask) || !NetworksAreIdentical(&values[i], expected[i]) {
+				t.Error("\n", "unexpected value", values[i], "expected", expected[i])
+			}
+		}
+	}
+}
+
+func TestAppendUnusedSubnets(t *testing.T) {
+	var reused []*net.IPNet
+	for _, input := range bufferedUnusedInputs {
+		aggregate := ParseNetworkCIDR(input.aggregate)
+		var subnets []*net.IPNet
+		for _, subnet := range input.subnets {
+			subnets = append(subnets, ParseNetworkCIDR(subnet))
+		}
+		expected := FindUnusedSubnets(aggregate, subnets...)
+		reused = AppendUnusedSubnets(reused[:0], aggregate, subnets...)
+		values := AppendUnusedSubnetValues(nil, aggregate, subnets...)
+		if !sliceOfSubnetsAreEqual(reused, expected) || len(values) != len(expected) {
+			t.Error(
+				"\n<<<input>>>\n", "aggregate:", aggregate, "\n", subnets,
+				"\n<<<actual_output>>>\n", reused,
+				"\n<<<expected_output>>>\n", expected,
+			)
+			continue
+		}
+		for i := range values {
+			if !NetworksAreIdentical(&values[i], expected[i]) {
+				t.Error("\n", "unexpected value", values[i], "expected", expected[i])
+			}
+		}
+	}
+}
+
+func TestAppendSubnetsAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector makes sync.Pool drop items so pooled scratch space is reallocated")
+	}
+	start, stop := net.ParseIP("192.168.1.2"), net.ParseIP("192.168.2.2")
+	aggregate := ParseNetworkCIDR("172.16.0.0/16")
+	subnets := []*net.IPNet{
+		ParseNetworkCIDR("172.16.255.16/30"),
+		ParseNetworkCIDR("172.16.11.0/24"),
+		ParseNetworkCIDR("172.16.40.0/22"),
+	}
+	v6start, v6stop := net.ParseIP("2001:400::"), net.ParseIP("2001:440:ffff:ffff:7fff:ffff:ffff:ffff")
+	pointers := AppendInbetweenSubnets(nil, v6start, v6stop)
+	values := AppendInbetweenSubnetValues(nil, v6start, v6stop)
+	tests := map[string]func(){
+		"AppendInbetweenSubnets": func() {
+			pointers = AppendInbetweenSubnets(pointers[:0], start, stop)
+			pointers = AppendInbetweenSubnets(pointers[:0], v6start, v6stop)
+		},
+		"AppendInbetweenSubnetValues": func() {
+			values = AppendInbetweenSubnetValues(values[:0], start, stop)
+			values = AppendInbetweenSubnetValues(values[:0], v6start, v6stop)
+		},
+		"AppendUnusedSubnets": func() {
+			pointers = AppendUnusedSubnets(pointers[:0], aggregate, subnets...)
+		},
+		"AppendUnusedSubnetValues": func() {
+			values = AppendUnusedSubnetValues(values[:0], aggregate, subnets...)
+		},
+	}
+	for name, test := range tests {
+		test()
+		if allocs := testing.AllocsPerRun(100, test); allocs != 0 {
+			t.Error("\n", name, "made", allocs, "allocations per run")
+		}
+	}
+}
+
+func BenchmarkAppendInbetweenSubnets(b *testing.B) {
+	alpha := net.ParseIP("192.168.0.0")
+	bravo := net.ParseIP("192.168.3.255")
+	var subnets []*net.IPNet
+	for i := 0; i < b.N; i++ {
+		subnets = AppendInbetweenSubnets(subnets[:0], alpha, bravo)
+	}
+}
+
+func BenchmarkAppendUnusedSubnets(b *testing.B) {
+	aggregate := ParseNetworkCIDR("192.168.0.0/22")
+	subnets := []*net.IPNet{
+		ParseNetworkCIDR("192.168.1.0/24"),
+		ParseNetworkCIDR("192.168.2.32/30"),
+	}
+	var unused []*net.IPNet
+	for i := 0; i < b.N; i++ {
+		unused = AppendUnusedSubnets(unused[:0], aggregate, subnets...)
+	}
+}
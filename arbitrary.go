@@ -0,0 +1,84 @@
+package subnetmath
+
+import (
+	"context"
+	"math/big"
+	"math/bits"
+	"net"
+)
+
+// defaultMaxExpansion bounds ExpandArbitraryMask when no MaxResults is given
+const defaultMaxExpansion = 1 << 16
+
+// MaskIsContiguous reports whether the mask is a canonical CIDR mask where every one bit
+// comes before every zero bit. Note that net.IPMask.Size returns 0, 0 for masks that
+// aren't contiguous which is easily mistaken for a /0.
+func MaskIsContiguous(mask net.IPMask) bool {
+	_, bits := mask.Size()
+	return bits != 0
+}
+
+// ArbitraryMaskSize returns the number of addresses matched by a network whose mask may
+// not be contiguous, such as the result of FromWildcard. Nil is returned for a nil network.
+func ArbitraryMaskSize(network *net.IPNet) *big.Int {
+	if network == nil {
+		return nil
+	}
+	zeros := 0
+	for _, b := range network.Mask {
+		zeros += 8 - bits.OnesCount8(b)
+	}
+	return new(big.Int).Lsh(bigOne, uint(zeros))
+}
+
+// ExpandArbitraryMask returns the sorted CIDR networks that together match exactly the same
+// addresses as a network whose mask may not be contiguous. Each zero bit that comes before the
+// last one bit of the mask doubles the number of networks, so the expansion is bounded by
+// MaxResults with a zero value treated as 65536. When the bound is exceeded the networks found
+// so far are returned along with a *LimitError.
+func ExpandArbitraryMask(network *net.IPNet, limits Limits) ([]*net.IPNet, error) {
+	if network == nil {
+		return nil, &ValidationError{"network", ErrNilNetwork}
+	}
+	length := len(network.Mask)
+	address := network.IP.To16()
+	family := IPv6
+	if length == net.IPv4len {
+		address = network.IP.To4()
+		family = IPv4
+	}
+	if address == nil || length != net.IPv4len && length != net.IPv6len {
+		return nil, &ValidationError{"network", ErrMismatchedMaskSize}
+	}
+	base := uint128FromBytes(address.Mask(network.Mask))
+	prefixLength := 0
+	for position := 0; position < length*8; position++ {
+		if getBit(network.Mask, position) == 1 {
+			prefixLength = position + 1
+		}
+	}
+	// holes are ordered from least to most significant so that counting upwards
+	// through their combinations produces the networks in ascending order
+	var holes []uint128
+	for position := prefixLength - 1; position >= 0; position-- {
+		if getBit(network.Mask, position) == 0 {
+			holes = append(holes, uint128Pow2(length*8-1-position))
+		}
+	}
+	if limits.MaxResults <= 0 {
+		limits.MaxResults = defaultMaxExpansion
+	}
+	collector := &limitedCollector{ctx: context.Background(), limits: limits}
+	for combination := uint64(0); len(holes) >= 64 || combination < 1<<uint(len(holes)); combination++ {
+		current := base
+		for i, hole := range holes {
+			if combination>>uint(i)&1 == 1 {
+				current = current.or(hole)
+			}
+		}
+		if !collector.emit(current, prefixLength, family) {
+			break
+		}
+	}
+	return collector.subnets, collector.err
+}
@@ -0,0 +1,99 @@
+package subnetmath
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+)
+
+func TestMaskIsContiguous(t *testing.T) {
+	tests := []struct {
+		input    net.IPMask
+		expected bool
+	}{
+		{net.CIDRMask(0, 32), true},
+		{net.CIDRMask(23, 32), true},
+		{net.CIDRMask(64, 128), true},
+		{net.IPv4Mask(255, 0, 255, 0), false},
+		{net.IPv4Mask(0, 0, 0, 255), false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if MaskIsContiguous(test.input) != test.expected {
+			t.Error("\n",
+				"<<<input>>>\n", test.input,
+				"\n<<<actual_output>>>\n", !test.expected,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
+
+func TestNonContiguousMasks(t *testing.T) {
+	first := &net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPv4Mask(255, 0, 255, 0)}
+	second := &net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPv4Mask(255, 255, 0, 255)}
+	if NetworksAreIdentical(first, second) {
+		t.Error("\n", first, "is not identical to", second)
+	}
+	if NextNetwork(first) != nil || BroadcastAddr(first) != nil || NewBuffer().BroadcastAddr(first) != nil {
+		t.Error("\n", "non-contiguous masks should not be treated as a /0")
+	}
+	if NetworkContainsSubnet(ParseNetworkCIDR("10.0.0.0/8"), first) {
+		t.Error("\n", "non-contiguous masks should not be treated as a /0")
+	}
+	aggregate := ParseNetworkCIDR("10.0.0.0/24")
+	used := []*net.IPNet{ParseNetworkCIDR("10.0.0.0/25"), first}
+	expected := []*net.IPNet{ParseNetworkCIDR("10.0.0.128/25")}
+	for _, output := range [][]*net.IPNet{
+		FindUnusedSubnets(aggregate, used...),
+		NewBuffer().FindUnusedSubnets(aggregate, used...),
+		AppendUnusedSubnets(nil, aggregate, used...),
+	} {
+		if !sliceOfSubnetsAreEqual(output, expected) {
+			t.Error(
+				"\n<<<input>>>\n", "aggregate:", aggregate, "\n", used,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+	if FindUnusedSubnets(first) != nil {
+		t.Error("\n", "a non-contiguous aggregate should return nil")
+	}
+}
+
+func TestArbitraryMaskSize(t *testing.T) {
+	input := FromWildcard(net.ParseIP("10.0.0.0"), net.IPv4Mask(0, 0, 2, 255))
+	if size := ArbitraryMaskSize(input); size.Cmp(big.NewInt(512)) != 0 {
+		t.Error("\n", "expected 512 addresses but got", size)
+	}
+}
+
+func TestExpandArbitraryMask(t *testing.T) {
+	input := FromWildcard(net.ParseIP("10.0.0.0"), net.IPv4Mask(0, 1, 2, 255))
+	output, err := ExpandArbitraryMask(input, Limits{})
+	expected := []*net.IPNet{
+		ParseNetworkCIDR("10.0.0.0/24"),
+		ParseNetworkCIDR("10.0.2.0/24"),
+		ParseNetworkCIDR("10.1.0.0/24"),
+		ParseNetworkCIDR("10.1.2.0/24"),
+	}
+	if err != nil || !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", output, err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	output, err = ExpandArbitraryMask(ParseNetworkCIDR("2001:db8::/32"), Limits{})
+	if err != nil || len(output) != 1 || !NetworksAreIdentical(output[0], ParseNetworkCIDR("2001:db8::/32")) {
+		t.Error("\n", "a contiguous mask should expand to itself but got", output, err)
+	}
+	input = FromWildcard(net.ParseIP("::"), net.CIDRMask(128, 128))
+	input.Mask[15] = 0xff
+	output, err = ExpandArbitraryMask(input, Limits{MaxResults: 10})
+	if !errors.Is(err, ErrLimitExceeded) || len(output) != 10 {
+		t.Error("\n", "expected 10 networks and ErrLimitExceeded but got", len(output), err)
+	}
+}
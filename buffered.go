@@ -2,35 +2,52 @@ package subnetmath
 
 import (
 	"bytes"
-	"math"
 	"math/big"
 	"net"
 	"sync"
 )
 
+// Buffer provides variants of the package functions that reuse scratch memory between calls.
+// A Buffer is safe for concurrent use and never serializes its callers. Each call borrows
+// its own scratch space from a sync.Pool and returns it once the call completes. The zero
+// value is ready for use.
+//
+// FindInbetweenSubnets and FindUnusedSubnets ignore the Buffer since the package functions
+// don't need scratch space, they're kept so that existing callers continue to work.
+//
+// Results never alias the scratch space of a Buffer. Unless a method says otherwise
+// the memory it returns is freshly allocated and owned by the caller. The AppendX
+// variants write into a caller supplied slice instead of allocating.
 type Buffer struct {
-	mtx           *sync.Mutex
-	bigIntAlpha   *big.Int
-	bigIntBravo   *big.Int
-	bigIntCharlie *big.Int
-	bigIntDelta   *big.Int
-	bigIntEcho    *big.Int
+	pool sync.Pool
+}
+
+// scratch is the per-call state borrowed from a Buffer
+type scratch struct {
+	bigIntAlpha   big.Int
+	bigIntBravo   big.Int
+	bigIntCharlie big.Int
 	ipSubZero     [16]byte
 }
 
+// NewBuffer returns a Buffer that is ready for use
 func NewBuffer() *Buffer {
-	return &Buffer{
-		mtx:           &sync.Mutex{},
-		bigIntAlpha:   new(big.Int),
-		bigIntBravo:   new(big.Int),
-		bigIntCharlie: new(big.Int),
-		bigIntDelta:   new(big.Int),
-		bigIntEcho:    new(big.Int),
-		ipSubZero:     [16]byte{},
+	return &Buffer{}
+}
+
+// get borrows scratch space from the pool or allocates it when the pool is empty
+func (b *Buffer) get() *scratch {
+	if s, ok := b.pool.Get().(*scratch); ok {
+		return s
 	}
+	return new(scratch)
 }
 
-var memoizedBigExp2 [128]*big.Int
+func (b *Buffer) put(s *scratch) {
+	b.pool.Put(s)
+}
+
+var memoizedBigExp2 [129]*big.Int
 
 func init() {
 	for i := range memoizedBigExp2 {
@@ -38,6 +55,17 @@ func init() {
 	}
 }
 
+// ParseNetworkCIDR is the buffered equivalent of ParseNetworkCIDR.
+// The result is owned by the caller.
+func (b *Buffer) ParseNetworkCIDR(cidr string) *net.IPNet {
+	return ParseNetworkCIDR(cidr)
+}
+
+// NetworksAreIdentical is the buffered equivalent of NetworksAreIdentical
+func (b *Buffer) NetworksAreIdentical(first, second *net.IPNet) bool {
+	return NetworksAreIdentical(first, second)
+}
+
 // NetworkComesBefore returns a bool with regards to numerical network order.
 // Note that IPv4 networks come before IPv6 networks.
 func (b *Buffer) NetworkComesBefore(first, second *net.IPNet) bool {
@@ -58,29 +86,109 @@ func (b *Buffer) NetworkComesBefore(first, second *net.IPNet) bool {
 // AddressComesBefore returns a bool with regards to numerical address order.
 // Note that IPv4 addresses come before IPv6 addresses.
 func (b *Buffer) AddressComesBefore(firstIP, secondIP net.IP) bool {
-	if firstIP.To4() == nil && secondIP.To4() != nil {
-		return false
-	} else if firstIP.To4() != nil && secondIP.To4() == nil {
-		return true
+	s := b.get()
+	defer b.put(s)
+	return s.addressComesBefore(firstIP, secondIP)
+}
+
+// DuplicateNetwork is the buffered equivalent of DuplicateNetwork.
+// The result is owned by the caller.
+func (b *Buffer) DuplicateNetwork(network *net.IPNet) *net.IPNet {
+	return DuplicateNetwork(network)
+}
+
+// DuplicateAddr is the buffered equivalent of DuplicateAddr.
+// The result is owned by the caller.
+func (b *Buffer) DuplicateAddr(addr net.IP) net.IP {
+	return DuplicateAddr(addr)
+}
+
+// SubnetZeroAddr returns the subnet zero address.
+// The result is owned by the caller, see AppendSubnetZeroAddr to avoid the allocation.
+func (b *Buffer) SubnetZeroAddr(address net.IP, network *net.IPNet) net.IP {
+	if zero := b.AppendSubnetZeroAddr(nil, address, network); len(zero) > 0 {
+		return zero
 	}
-	b.mtx.Lock()
-	defer b.mtx.Unlock()
-	if b.addrToIntAlpha(firstIP).Cmp(b.addrToIntBravo(secondIP)) < 0 {
-		return true
+	return nil
+}
+
+// AppendSubnetZeroAddr appends the 16 byte subnet zero address to dst and returns the
+// extended slice. Dst is returned unchanged if the address and network are incompatible.
+func (b *Buffer) AppendSubnetZeroAddr(dst net.IP, address net.IP, network *net.IPNet) net.IP {
+	s := b.get()
+	defer b.put(s)
+	return append(dst, s.subnetZeroAddr(address, network)...)
+}
+
+// NextNetwork returns the next network of the same size or nil if the network is the
+// last one of its size in the address family or its mask isn't contiguous.
+// The result is owned by the caller.
+func (b *Buffer) NextNetwork(network *net.IPNet) *net.IPNet {
+	s := b.get()
+	defer b.put(s)
+	return s.nextNetwork(network)
+}
+
+// BroadcastAddr returns the broadcast address or nil if the mask isn't contiguous.
+// The result is owned by the caller, see AppendBroadcastAddr to avoid the allocation.
+func (b *Buffer) BroadcastAddr(network *net.IPNet) net.IP {
+	if broadcast := b.AppendBroadcastAddr(nil, network); len(broadcast) > 0 {
+		return broadcast
 	}
-	return false
+	return nil
+}
+
+// AppendBroadcastAddr appends the 16 byte broadcast address to dst and returns the
+// extended slice. Dst is returned unchanged if the network is nil or its mask isn't contiguous.
+func (b *Buffer) AppendBroadcastAddr(dst net.IP, network *net.IPNet) net.IP {
+	if network != nil && MaskIsContiguous(network.Mask) {
+		s := b.get()
+		defer b.put(s)
+		networkInt := s.addrToIntAlpha(network.IP)
+		networkInt.Add(networkInt, s.addressCountCharlie(network))
+		networkInt.Sub(networkInt, bigOne)
+		return append(dst, s.intToAddrFamily(networkInt, AddrFamily(network.IP))...)
+	}
+	return dst
+}
+
+// NextAddr returns a new net.IP that is the next address or nil if the address
+// is the last one in the address family. The result is owned by the caller,
+// see AppendNextAddr to avoid the allocation.
+func (b *Buffer) NextAddr(addr net.IP) net.IP {
+	if next := b.AppendNextAddr(nil, addr); len(next) > 0 {
+		return next
+	}
+	return nil
+}
+
+// AppendNextAddr appends the 16 byte next address to dst and returns the extended slice.
+// Dst is returned unchanged if the address is the last one in the address family.
+func (b *Buffer) AppendNextAddr(dst net.IP, addr net.IP) net.IP {
+	s := b.get()
+	defer b.put(s)
+	addrInt := s.addrToIntAlpha(addr)
+	addrInt.Add(addrInt, bigOne)
+	return append(dst, s.intToAddrFamily(addrInt, AddrFamily(addr))...)
 }
 
-// NetworkContainsSubnet validates that the network is a valid supernet
+// ShrinkNetwork is the buffered equivalent of ShrinkNetwork.
+// Note that the network is modified in place and returned rather than copied.
+func (b *Buffer) ShrinkNetwork(network *net.IPNet) *net.IPNet {
+	return ShrinkNetwork(network)
+}
+
+// NetworkContainsSubnet validates that the network is a valid supernet.
+// False is returned if either mask isn't contiguous.
 func (b *Buffer) NetworkContainsSubnet(network *net.IPNet, subnet *net.IPNet) bool {
-	if network != nil && subnet != nil {
-		b.mtx.Lock()
-		defer b.mtx.Unlock()
-		supernetInt := b.addrToIntAlpha(network.IP)
-		subnetInt := b.addrToIntBravo(subnet.IP)
+	if network != nil && subnet != nil && MaskIsContiguous(network.Mask) && MaskIsContiguous(subnet.Mask) {
+		s := b.get()
+		defer b.put(s)
+		supernetInt := s.addrToIntAlpha(network.IP)
+		subnetInt := s.addrToIntBravo(subnet.IP)
 		if supernetInt.Cmp(subnetInt) <= 0 {
-			supernetInt.Add(supernetInt, b.addressCountCharlieDelta(network))
-			subnetInt.Add(subnetInt, b.addressCountCharlieDelta(subnet))
+			supernetInt.Add(supernetInt, s.addressCountCharlie(network))
+			subnetInt.Add(subnetInt, s.addressCountCharlie(subnet))
 			if supernetInt.Cmp(subnetInt) >= 0 {
 				return true
 			}
@@ -89,89 +197,130 @@ func (b *Buffer) NetworkContainsSubnet(network *net.IPNet, subnet *net.IPNet) bo
 	return false
 }
 
-func (b *Buffer) addrToIntAlpha(address net.IP) *big.Int {
+// FindInbetweenSubnets returns a slice of subnets given a range of IP addresses.
+// Note that the delimiter 'stop' is inclusive. In other words, it will be included in the result.
+// The slice and every subnet within it are owned by the caller.
+func (b *Buffer) FindInbetweenSubnets(start, stop net.IP) []*net.IPNet {
+	return AppendInbetweenSubnets(nil, start, stop)
+}
+
+// FindUnusedSubnets returns a slice of unused subnets given the aggregate and sibling subnets.
+// The slice and every subnet within it are owned by the caller and never alias the aggregate
+// or sibling subnets.
+func (b *Buffer) FindUnusedSubnets(aggregate *net.IPNet, subnets ...*net.IPNet) []*net.IPNet {
+	return AppendUnusedSubnets(nil, aggregate, subnets...)
+}
+
+// IntToAddr is the buffered equivalent of IntToAddr.
+// The result is owned by the caller.
+func (b *Buffer) IntToAddr(intAddress *big.Int) net.IP {
+	return IntToAddr(intAddress)
+}
+
+// IntToAddrFamily is the buffered equivalent of IntToAddrFamily.
+// The result is owned by the caller.
+func (b *Buffer) IntToAddrFamily(intAddress *big.Int, family Family) net.IP {
+	return IntToAddrFamily(intAddress, family)
+}
+
+// AddrToInt is the buffered equivalent of AddrToInt.
+// The result is owned by the caller.
+func (b *Buffer) AddrToInt(address net.IP) *big.Int {
+	return AddrToInt(address)
+}
+
+// AddrFamily is the buffered equivalent of AddrFamily
+func (b *Buffer) AddrFamily(address net.IP) Family {
+	return AddrFamily(address)
+}
+
+// IPv4ClassfulNetwork is the buffered equivalent of IPv4ClassfulNetwork.
+// The result is owned by the caller.
+func (b *Buffer) IPv4ClassfulNetwork(address net.IP) *net.IPNet {
+	return IPv4ClassfulNetwork(address)
+}
+
+func (s *scratch) addressComesBefore(firstIP, secondIP net.IP) bool {
+	if firstIP.To4() == nil && secondIP.To4() != nil {
+		return false
+	} else if firstIP.To4() != nil && secondIP.To4() == nil {
+		return true
+	}
+	if s.addrToIntAlpha(firstIP).Cmp(s.addrToIntBravo(secondIP)) < 0 {
+		return true
+	}
+	return false
+}
+
+func (s *scratch) addrToIntAlpha(address net.IP) *big.Int {
 	v4addr := address.To4()
 	if v4addr != nil {
-		b.bigIntAlpha.SetBytes(v4addr)
+		s.bigIntAlpha.SetBytes(v4addr)
 	} else {
-		b.bigIntAlpha.SetBytes(address.To16())
+		s.bigIntAlpha.SetBytes(address.To16())
 	}
-	return b.bigIntAlpha
+	return &s.bigIntAlpha
 }
 
-func (b *Buffer) addrToIntBravo(address net.IP) *big.Int {
+func (s *scratch) addrToIntBravo(address net.IP) *big.Int {
 	v4addr := address.To4()
 	if v4addr != nil {
-		b.bigIntBravo.SetBytes(v4addr)
+		s.bigIntBravo.SetBytes(v4addr)
 	} else {
-		b.bigIntBravo.SetBytes(address.To16())
+		s.bigIntBravo.SetBytes(address.To16())
 	}
-	return b.bigIntBravo
+	return &s.bigIntBravo
 }
 
-func (b *Buffer) addressCountCharlieDelta(network *net.IPNet) *big.Int {
+func (s *scratch) addressCountCharlie(network *net.IPNet) *big.Int {
 	if network != nil {
 		ones, bits := network.Mask.Size()
 		if bits <= 32 {
-			return b.bigIntCharlie.SetInt64(int64(math.Exp2(float64(bits - ones))))
+			return s.bigIntCharlie.SetInt64(1 << uint(bits-ones))
 		}
 		return memoizedBigExp2[bits-ones]
 	}
 	return nil
 }
 
-func (b *Buffer) nextNetworkEcho(network *net.IPNet) *net.IPNet {
-	if network != nil {
-		nextNetwork := DuplicateNetwork(network)
-		v4addr := network.IP.To4()
-		if v4addr != nil {
-			b.bigIntEcho.SetBytes(v4addr)
-		} else {
-			b.bigIntEcho.SetBytes(network.IP.To16())
+func (s *scratch) nextNetwork(network *net.IPNet) *net.IPNet {
+	if network != nil && MaskIsContiguous(network.Mask) {
+		networkInt := s.addrToIntAlpha(network.IP)
+		networkInt.Add(networkInt, s.addressCountCharlie(network))
+		nextAddr := IntToAddrFamily(networkInt, AddrFamily(network.IP))
+		if nextAddr != nil {
+			nextMask := make(net.IPMask, len(network.Mask))
+			copy(nextMask, network.Mask)
+			return canonicalNetwork(nextAddr, nextMask)
 		}
-		b.bigIntEcho.Add(b.bigIntEcho, b.addressCountCharlieDelta(network))
-		nextNetwork.IP = IntToAddr(b.bigIntEcho)
-		return nextNetwork
 	}
 	return nil
 }
 
-// FindInbetweenSubnets returns a slice of subnets given a range of IP addresses.
-// Note that the delimiter 'stop' is inclusive. In other words, it will be included in the result.
-func (b *Buffer) FindInbetweenSubnets(start, stop net.IP) []*net.IPNet {
-	if sameAddrType(start, stop) && b.AddressComesBefore(start, stop) {
-		var subnets []*net.IPNet
-		maskBits := maskBitLength(start)
-		current := DuplicateAddr(start)
-		stopInt := b.addrToIntAlpha(stop)
-		for {
-			currentSubnet := &net.IPNet{
-				IP:   current,
-				Mask: make(net.IPMask, maskBits/8),
-			}
-			for ones := 1; ones <= maskBits; ones++ {
-				currentSubnet.Mask = recreateMask(currentSubnet.Mask, ones, maskBits)
-				increment := b.addressCountCharlieDelta(currentSubnet)
-				addressInt := b.addrToIntBravo(currentSubnet.IP)
-				addressInt.Add(addressInt, increment)
-				addressInt.Sub(addressInt, bigOne)
-				if addressInt.Cmp(stopInt) > 0 {
-					continue
-				}
-				if b.SubnetZeroAddr(currentSubnet.IP, currentSubnet).Equal(currentSubnet.IP) {
-					break
-				}
-			}
-			subnets = append(subnets, currentSubnet)
-			current = b.nextNetworkEcho(currentSubnet).IP
-			if b.AddressComesBefore(current, start) {
-				break
-			}
-			if b.AddressComesBefore(stop, current) && !current.Equal(stop) {
-				break
-			}
+// intToAddrFamily is IntToAddrFamily writing into the scratch memory. The result
+// is only valid until the scratch is returned to the pool.
+func (s *scratch) intToAddrFamily(intAddress *big.Int, family Family) net.IP {
+	switch {
+	case intAddress.Sign() < 0:
+		return nil
+	case family == IPv4 && intAddress.BitLen() <= 32:
+		copy(s.ipSubZero[:], v4InV6Prefix)
+		intAddress.FillBytes(s.ipSubZero[len(v4InV6Prefix):])
+		return s.ipSubZero[:]
+	case family == IPv6 && intAddress.BitLen() <= 128:
+		return intAddress.FillBytes(s.ipSubZero[:])
+	}
+	return nil
+}
+
+// subnetZeroAddr returns a slice of the scratch memory which is only valid
+// until the scratch is returned to the pool
+func (s *scratch) subnetZeroAddr(address net.IP, network *net.IPNet) net.IP {
+	if network != nil {
+		if copy(s.ipSubZero[:], address.To16()) == net.IPv6len &&
+			applyMaskDirectly(s.ipSubZero[:], network.Mask) != nil {
+			return s.ipSubZero[:]
 		}
-		return subnets
 	}
 	return nil
 }
@@ -202,37 +351,3 @@ func applyMaskDirectly(ip net.IP, mask net.IPMask) net.IP {
 	}
 	return nil
 }
-
-// SubnetZeroAddr returns the subnet zero address
-func (b *Buffer) SubnetZeroAddr(address net.IP, network *net.IPNet) net.IP {
-	if network != nil {
-		b.mtx.Lock()
-		defer b.mtx.Unlock()
-		for i := 0; i < len(address); i++ {
-			b.ipSubZero[i] = address[i]
-		}
-		return applyMaskDirectly(b.ipSubZero[:len(address)], network.Mask)
-	}
-	return nil
-}
-
-func recreateMask(mask net.IPMask, ones, bits int) net.IPMask {
-	if bits != 8*net.IPv4len && bits != 8*net.IPv6len {
-		return nil
-	}
-	if ones < 0 || ones > bits {
-		return nil
-	}
-	l := bits / 8
-	n := uint(ones)
-	for i := 0; i < l; i++ {
-		if n >= 8 {
-			mask[i] = 0xff
-			n -= 8
-			continue
-		}
-		mask[i] = ^byte(0xff >> n)
-		n = 0
-	}
-	return mask
-}
@@ -0,0 +1,213 @@
+package subnetmath
+
+import (
+	"net"
+	"testing"
+)
+
+var bufferedUnusedInputs = []struct {
+	aggregate string
+	subnets   []string
+}{
+	{"10.71.8.0/21", nil},
+	{"192.168.0.0/22", []string{"192.168.1.0/24", "192.168.2.32/30"}},
+	{"192.168.0.0/22", []string{"192.168.2.32/30", "192.168.1.0/24", "10.0.0.0/8"}},
+	{"192.168.0.0/22", []string{"192.168.0.0/16"}},
+	{"255.255.255.0/24", []string{"255.255.255.255/32", "255.255.255.0/32"}},
+	{"2001:db8::/32", []string{"2001:db8:8000::/33", "2001:db8:1::/48"}},
+	{"::/0", []string{"::/128", "8000::/1"}},
+}
+
+func TestBufferNetworkContainsSubnet(t *testing.T) {
+	buf := NewBuffer()
+	for _, input := range bufferedUnusedInputs {
+		aggregate := ParseNetworkCIDR(input.aggregate)
+		var subnets []*net.IPNet
+		for _, subnet := range input.subnets {
+			subnets = append(subnets, ParseNetworkCIDR(subnet))
+		}
+		for _, unused := range FindUnusedSubnets(aggregate, subnets...) {
+			for _, network := range append([]*net.IPNet{aggregate}, subnets...) {
+				output := [2]bool{buf.NetworkContainsSubnet(network, unused), buf.NetworkContainsSubnet(unused, network)}
+				expected := [2]bool{NetworkContainsSubnet(network, unused), NetworkContainsSubnet(unused, network)}
+				if output != expected {
+					t.Error(
+						"\n<<<input>>>\n", network, unused,
+						"\n<<<actual_output>>>\n", output,
+						"\n<<<expected_output>>>\n", expected,
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestBufferAddressComesBefore(t *testing.T) {
+	buf := NewBuffer()
+	inputs := [][]net.IP{
+		{net.ParseIP("192.168.1.2"), net.ParseIP("192.168.2.2")},
+		{net.ParseIP("0.0.0.0"), net.ParseIP("255.255.255.255")},
+		{net.ParseIP("2001:400::"), net.ParseIP("2001:440:ffff:ffff:7fff:ffff:ffff:ffff")},
+		{net.ParseIP("::"), net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")},
+		{net.ParseIP("192.168.1.2"), net.ParseIP("192.168.1.2")},
+		{net.ParseIP("192.168.1.2"), net.ParseIP("2001:db8::")},
+	}
+	for _, input := range inputs {
+		output := [2]bool{buf.AddressComesBefore(input[0], input[1]), buf.AddressComesBefore(input[1], input[0])}
+		expected := [2]bool{AddressComesBefore(input[0], input[1]), AddressComesBefore(input[1], input[0])}
+		if output != expected {
+			t.Error("\n",
+				"<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
+
+func TestBufferAddressFunctions(t *testing.T) {
+	buf := NewBuffer()
+	for _, cidr := range []string{"192.168.0.0/23", "255.255.255.254/31", "2001:db8::/64", "::/128"} {
+		network := ParseNetworkCIDR(cidr)
+		if !NetworksAreIdentical(buf.NextNetwork(network), NextNetwork(network)) &&
+			!(buf.NextNetwork(network) == nil && NextNetwork(network) == nil) {
+			t.Error("\n", "NextNetwork differs for", cidr)
+		}
+		if !buf.BroadcastAddr(network).Equal(BroadcastAddr(network)) {
+			t.Error("\n", "BroadcastAddr differs for", cidr)
+		}
+		if !buf.NextAddr(network.IP).Equal(NextAddr(network.IP)) {
+			t.Error("\n", "NextAddr differs for", cidr)
+		}
+	}
+}
+
+func BenchmarkBufferNetworkContainsSubnetParallel(b *testing.B) {
+	network := ParseNetworkCIDR("192.168.0.0/22")
+	subnet := ParseNetworkCIDR("192.168.2.32/30")
+	buf := NewBuffer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf.NetworkContainsSubnet(network, subnet)
+		}
+	})
+}
+
+func BenchmarkBufferAddressComesBeforeParallel(b *testing.B) {
+	alpha := net.ParseIP("192.168.0.0")
+	bravo := net.ParseIP("192.168.3.255")
+	buf := NewBuffer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf.AddressComesBefore(alpha, bravo)
+		}
+	})
+}
+
+func BenchmarkBufferNextNetworkParallel(b *testing.B) {
+	buf := NewBuffer()
+	b.RunParallel(func(pb *testing.PB) {
+		network := ParseNetworkCIDR("192.168.0.0/28")
+		for pb.Next() {
+			network = buf.NextNetwork(network)
+		}
+	})
+}
+
+func TestBufferAppendVariants(t *testing.T) {
+	buf := NewBuffer()
+	network := ParseNetworkCIDR("192.168.0.0/23")
+	dst := make(net.IP, 0, 3*net.IPv6len)
+	dst = buf.AppendSubnetZeroAddr(dst, net.ParseIP("192.168.1.7"), network)
+	dst = buf.AppendBroadcastAddr(dst, network)
+	dst = buf.AppendNextAddr(dst, net.ParseIP("192.168.1.7"))
+	expected := []net.IP{
+		net.ParseIP("192.168.0.0"),
+		net.ParseIP("192.168.1.255"),
+		net.ParseIP("192.168.1.8"),
+	}
+	if len(dst) != len(expected)*net.IPv6len {
+		t.Fatal("\n", "unexpected length", len(dst))
+	}
+	for i := range expected {
+		actual := dst[i*net.IPv6len : (i+1)*net.IPv6len]
+		if !actual.Equal(expected[i]) {
+			t.Error("\n",
+				"<<<actual_output>>>\n", actual,
+				"\n<<<expected_output>>>\n", expected[i],
+			)
+		}
+	}
+	if len(buf.AppendNextAddr(nil, net.ParseIP("255.255.255.255"))) != 0 {
+		t.Error("\n", "the last address should not append anything")
+	}
+}
+
+// TestBufferSharedConcurrently is most useful when run with the race detector
+func TestBufferSharedConcurrently(t *testing.T) {
+	buf := NewBuffer()
+	inputs := []*net.IPNet{
+		ParseNetworkCIDR("10.0.0.0/8"),
+		ParseNetworkCIDR("192.168.0.0/23"),
+		ParseNetworkCIDR("172.16.5.4/30"),
+		ParseNetworkCIDR("2001:db8::/48"),
+		ParseNetworkCIDR("fe80::/64"),
+	}
+	errs := make(chan string, 8)
+	done := make(chan struct{})
+	for g := 0; g < 8; g++ {
+		go func(g int) {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 50; i++ {
+				network := inputs[(g+i)%len(inputs)]
+				other := inputs[(g+i+1)%len(inputs)]
+				addr := NextAddr(network.IP)
+				zero := buf.SubnetZeroAddr(addr, network)
+				next := buf.NextNetwork(network)
+				broadcast := buf.BroadcastAddr(network)
+				nextAddr := buf.NextAddr(addr)
+				before := buf.NetworkComesBefore(network, other)
+				contains := buf.NetworkContainsSubnet(network, other)
+				switch {
+				case !zero.Equal(SubnetZeroAddr(addr, network)):
+					errs <- "SubnetZeroAddr"
+				case !NetworksAreIdentical(next, NextNetwork(network)):
+					errs <- "NextNetwork"
+				case !broadcast.Equal(BroadcastAddr(network)):
+					errs <- "BroadcastAddr"
+				case !nextAddr.Equal(NextAddr(addr)):
+					errs <- "NextAddr"
+				case before != NetworkComesBefore(network, other):
+					errs <- "NetworkComesBefore"
+				case contains != NetworkContainsSubnet(network, other):
+					errs <- "NetworkContainsSubnet"
+				default:
+					continue
+				}
+				return
+			}
+		}(g)
+	}
+	for g := 0; g < 8; g++ {
+		<-done
+	}
+	close(errs)
+	for name := range errs {
+		t.Error("\n", name, "returned an unexpected result while the buffer was shared")
+	}
+}
+
+func TestBufferZeroValue(t *testing.T) {
+	var buf Buffer
+	aggregate := ParseNetworkCIDR("192.168.0.0/22")
+	used := ParseNetworkCIDR("192.168.1.0/24")
+	if !buf.NetworkContainsSubnet(aggregate, used) || !buf.AddressComesBefore(aggregate.IP, used.IP) ||
+		!buf.NextAddr(aggregate.IP).Equal(net.ParseIP("192.168.0.1")) {
+		t.Error(
+			"\n<<<input>>>\n", "aggregate:", aggregate, "\n", used,
+			"\n<<<actual_output>>>\n", buf.NetworkContainsSubnet(aggregate, used), buf.AddressComesBefore(aggregate.IP, used.IP),
+			buf.NextAddr(aggregate.IP),
+			"\n<<<expected_output>>>\n", true, true, "192.168.0.1",
+		)
+	}
+}
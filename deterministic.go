@@ -0,0 +1,74 @@
+package subnetmath
+
+import (
+	"crypto/sha256"
+	"net"
+	"sort"
+)
+
+// maxDeterministicProbes bounds the probing of DeterministicSubnet before it falls back to
+// examining every free block
+const maxDeterministicProbes = 64
+
+// DeterministicSubnet returns a subnet of the aggregate with the prefix length that doesn't
+// overlap any of the used subnets along with the number of candidate subnets that were
+// probed to find it. The same key, aggregate and used subnets always result in the same
+// subnet, so callers can assign subnets to tenants without central state.
+//
+// The candidates are the 2^(prefixLength-aggregateLength) subnets of the aggregate. Probing
+// starts at the candidate chosen by the SHA-256 hash of the key and continues with triangular
+// steps of 1, 2, 3 and so on, which visits every candidate as their number is a power of two.
+// A candidate is free if it's within the free space found the same way as FindUnusedSubnets.
+// The expected number of probes is the number of candidates divided by the number of free
+// candidates. After 64 probes the free candidate with the smallest XOR distance to the
+// starting candidate is returned instead, which takes one probe per free block, so sparse
+// free space doesn't need a number of probes proportional to the candidates. Nil and zero
+// are returned if there is no free candidate, the aggregate isn't a valid CIDR network, or
+// the prefix length is shorter than the aggregate or longer than the address family allows.
+func DeterministicSubnet(aggregate *net.IPNet, prefixLength int, key []byte, used ...*net.IPNet) (*net.IPNet, int) {
+	first, _, family := uint128Range(aggregate)
+	if family == 0 || !MaskIsContiguous(aggregate.Mask) {
+		return nil, 0
+	}
+	ones, bits := familyPrefixLength(aggregate)
+	if prefixLength < ones || prefixLength > bits {
+		return nil, 0
+	}
+	var free []addrRange
+	walkUnusedSubnets(aggregate, used, func(addr uint128, blockOnes int, _ Family) bool {
+		if blockOnes <= prefixLength {
+			free = append(free, addrRange{addr, addr.or(uint128Ones(bits - blockOnes))})
+		}
+		return true
+	})
+	if len(free) == 0 {
+		return nil, 0
+	}
+	slotBits := prefixLength - ones
+	slotMask := uint128Ones(slotBits)
+	sum := sha256.Sum256(key)
+	slot := uint128FromBytes(sum[:16]).and(slotMask)
+	start := first.or(slot.lsh(bits - prefixLength))
+	step := uint128{}
+	for probes := 1; probes <= maxDeterministicProbes; probes++ {
+		candidate := first.or(slot.lsh(bits - prefixLength))
+		// free blocks are aligned and at least as large as a candidate, so a free candidate
+		// is within the last block that starts at or before it
+		i := sort.Search(len(free), func(i int) bool { return free[i].first.cmp(candidate) > 0 }) - 1
+		if i >= 0 && candidate.cmp(free[i].last) <= 0 {
+			return appendNetworkPointer(nil, candidate, prefixLength, family)[0], probes
+		}
+		step, _ = step.add(uint128{0, 1})
+		slot, _ = slot.add(step)
+		slot = slot.and(slotMask)
+	}
+	// the closest candidate of a block keeps the host bits of the block from the start
+	var best, bestDistance uint128
+	for i, block := range free {
+		candidate := block.first.or(start.and(block.last.sub(block.first)))
+		if distance := candidate.xor(start); i == 0 || distance.cmp(bestDistance) < 0 {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return appendNetworkPointer(nil, best, prefixLength, family)[0], maxDeterministicProbes + len(free)
+}
@@ -0,0 +1,132 @@
+package subnetmath
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestDeterministicSubnet(t *testing.T) {
+	aggregate := ParseNetworkCIDR("10.0.0.0/16")
+	first, probes := DeterministicSubnet(aggregate, 24, []byte("tenant-1"))
+	again, againProbes := DeterministicSubnet(aggregate, 24, []byte("tenant-1"))
+	ones, _ := first.Mask.Size()
+	if probes != 1 || againProbes != 1 || !NetworksAreIdentical(first, again) || ones != 24 ||
+		!NetworkContainsSubnet(aggregate, first) {
+		t.Fatal(
+			"\n<<<input>>>\n", aggregate, 24, "tenant-1",
+			"\n<<<actual_output>>>\n", first, probes, again, againProbes,
+			"\n<<<expected_output>>>\n", "the same /24 after one probe",
+		)
+	}
+	// taking the preferred subnet moves the tenant to its next probe
+	moved, movedProbes := DeterministicSubnet(aggregate, 24, []byte("tenant-1"), first)
+	if movedProbes != 2 || NetworksAreIdentical(moved, first) {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, 24, "tenant-1", first,
+			"\n<<<actual_output>>>\n", moved, movedProbes,
+			"\n<<<expected_output>>>\n", "another /24 after two probes",
+		)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		subnet, _ := DeterministicSubnet(aggregate, 24, []byte(fmt.Sprint("tenant-", i)))
+		seen[subnet.String()] = true
+	}
+	if len(seen) < 75 {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, 24, "100 tenants",
+			"\n<<<actual_output>>>\n", len(seen),
+			"\n<<<expected_output>>>\n", "mostly distinct subnets",
+		)
+	}
+}
+
+func TestDeterministicSubnetProbing(t *testing.T) {
+	aggregate := ParseNetworkCIDR("192.168.0.0/24")
+	// every /28 but 192.168.0.176/28 is used
+	var used []*net.IPNet
+	for i := 0; i < 16; i++ {
+		if i != 11 {
+			used = append(used, ParseNetworkCIDR(fmt.Sprintf("192.168.0.%d/28", i*16)))
+		}
+	}
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprint(i))
+		subnet, probes := DeterministicSubnet(aggregate, 28, key, used...)
+		if subnet.String() != "192.168.0.176/28" || probes < 1 || probes > 16 {
+			t.Fatal(
+				"\n<<<input>>>\n", aggregate, 28, key,
+				"\n<<<actual_output>>>\n", subnet, probes,
+				"\n<<<expected_output>>>\n", "192.168.0.176/28 within 16 probes",
+			)
+		}
+	}
+	used = append(used, ParseNetworkCIDR("192.168.0.176/30"))
+	if subnet, probes := DeterministicSubnet(aggregate, 28, []byte("full"), used...); subnet != nil || probes != 0 {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, 28, used,
+			"\n<<<actual_output>>>\n", subnet, probes,
+			"\n<<<expected_output>>>\n", nil, 0,
+		)
+	}
+}
+
+func TestDeterministicSubnetIPv6(t *testing.T) {
+	for _, test := range []struct {
+		aggregate    string
+		prefixLength int
+	}{
+		{"2001:db8::/32", 64},
+		{"::/0", 128},
+		{"2001:db8::/64", 64},
+	} {
+		aggregate := ParseNetworkCIDR(test.aggregate)
+		subnet, probes := DeterministicSubnet(aggregate, test.prefixLength, []byte("tenant"))
+		ones, _ := subnet.Mask.Size()
+		if probes != 1 || ones != test.prefixLength || !NetworkContainsSubnet(aggregate, subnet) || ValidateNetwork(subnet) != nil {
+			t.Error(
+				"\n<<<input>>>\n", test.aggregate, test.prefixLength,
+				"\n<<<actual_output>>>\n", subnet, probes,
+				"\n<<<expected_output>>>\n", "a subnet of the aggregate after one probe",
+			)
+		}
+	}
+	if subnet, probes := DeterministicSubnet(ParseNetworkCIDR("2001:db8::/32"), 31, []byte("tenant")); subnet != nil || probes != 0 {
+		t.Error(
+			"\n<<<input>>>\n", "2001:db8::/32", 31,
+			"\n<<<actual_output>>>\n", subnet, probes,
+			"\n<<<expected_output>>>\n", nil, 0,
+		)
+	}
+}
+
+func TestDeterministicSubnetSparse(t *testing.T) {
+	aggregate := ParseNetworkCIDR("2001:db8::/32")
+	target := ParseNetworkCIDR("2001:db8:1234::/128")
+	// everything but a single address of 2^96 candidates is used
+	used := FindUnusedSubnets(aggregate, target)
+	subnet, probes := DeterministicSubnet(aggregate, 128, []byte("tenant"), used...)
+	if !NetworksAreIdentical(subnet, target) || probes != maxDeterministicProbes+1 {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, 128, len(used),
+			"\n<<<actual_output>>>\n", subnet, probes,
+			"\n<<<expected_output>>>\n", target, maxDeterministicProbes+1,
+		)
+	}
+	free := []*net.IPNet{ParseNetworkCIDR("2001:db8:1234::/126"), ParseNetworkCIDR("2001:db8:ffff::/112")}
+	used = FindUnusedSubnets(aggregate, free...)
+	for i := 0; i < 20; i++ {
+		key := []byte(fmt.Sprint("tenant-", i))
+		subnet, probes := DeterministicSubnet(aggregate, 128, key, used...)
+		again, _ := DeterministicSubnet(aggregate, 128, key, used...)
+		if !NetworksAreIdentical(subnet, again) || probes != maxDeterministicProbes+2 ||
+			!NetworkContainsSubnet(free[0], subnet) && !NetworkContainsSubnet(free[1], subnet) {
+			t.Fatal(
+				"\n<<<input>>>\n", aggregate, 128, key, free,
+				"\n<<<actual_output>>>\n", subnet, again, probes,
+				"\n<<<expected_output>>>\n", "the same free /128 after examining both free blocks",
+			)
+		}
+	}
+}
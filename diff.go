@@ -0,0 +1,245 @@
+package subnetmath
+
+import (
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+)
+
+// ChangeKind describes how networks changed between two inventories
+type ChangeKind int
+
+// kinds of change
+const (
+	// Added networks don't overlap any old network
+	Added ChangeKind = iota
+	// Removed networks don't overlap any new network
+	Removed
+	// Split networks were replaced by several new networks within them
+	Split
+	// Merged networks were replaced by a single new network containing them
+	Merged
+	// Resized covers any other overlap between old and new networks
+	Resized
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Split:
+		return "split"
+	case Merged:
+		return "merged"
+	case Resized:
+		return "resized"
+	}
+	return "unknown"
+}
+
+// NetworkChange is a group of old and new networks that overlap one another
+type NetworkChange struct {
+	Kind ChangeKind
+	Old  []*net.IPNet
+	New  []*net.IPNet
+	// AddressDelta is the number of addresses covered by New less those covered by Old
+	AddressDelta *big.Int
+}
+
+func (c NetworkChange) String() string {
+	var b strings.Builder
+	b.WriteString(c.Kind.String())
+	for _, network := range c.Old {
+		b.WriteString(" " + network.String())
+	}
+	if len(c.Old) > 0 && len(c.New) > 0 {
+		b.WriteString(" ->")
+	}
+	for _, network := range c.New {
+		b.WriteString(" " + network.String())
+	}
+	return b.String()
+}
+
+// NetworkDiff is the result of DiffNetworks
+type NetworkDiff struct {
+	// Changes are ordered by family, first address and then largest network first
+	Changes []NetworkChange
+	// Unchanged holds the networks that are identical in both inventories and don't overlap
+	// any network that changed
+	Unchanged []*net.IPNet
+	// AddressDelta is the number of addresses covered by the new inventory less those
+	// covered by the old inventory
+	AddressDelta *big.Int
+}
+
+// diffItem is a valid network from either inventory
+type diffItem struct {
+	network     *net.IPNet
+	first, last uint128
+	family      Family
+	isNew       bool
+}
+
+// diffKey identifies networks that are identical
+type diffKey struct {
+	first, last uint128
+	family      Family
+}
+
+func (i diffItem) key() diffKey {
+	return diffKey{i.first, i.last, i.family}
+}
+
+// DiffNetworks compares two inventories of networks. Networks are grouped with every network
+// they transitively overlap in either inventory. Groups where the old and new networks are
+// identical are unchanged and the others describe what moved. Networks that aren't valid CIDR
+// networks are ignored.
+func DiffNetworks(oldNetworks, newNetworks []*net.IPNet) NetworkDiff {
+	oldItems, newItems := diffItems(oldNetworks, false), diffItems(newNetworks, true)
+	diff := NetworkDiff{AddressDelta: new(big.Int).Sub(unionSize(newItems), unionSize(oldItems))}
+	items := append(oldItems, newItems...)
+	sortDiffItems(items)
+	for start := 0; start < len(items); {
+		end, last := start+1, items[start].last
+		for ; end < len(items); end++ {
+			item := items[end]
+			if item.family != items[start].family || item.first.cmp(last) > 0 {
+				break
+			}
+			if item.last.cmp(last) > 0 {
+				last = item.last
+			}
+		}
+		if group := items[start:end]; identicalItems(group) {
+			for _, item := range group {
+				if item.isNew {
+					diff.Unchanged = append(diff.Unchanged, item.network)
+				}
+			}
+		} else {
+			diff.Changes = append(diff.Changes, newNetworkChange(group))
+		}
+		start = end
+	}
+	return diff
+}
+
+// identicalItems reports whether every old network of the group has an identical new
+// network and the other way around
+func identicalItems(items []diffItem) bool {
+	counts := map[diffKey]int{}
+	for _, item := range items {
+		if item.isNew {
+			counts[item.key()]++
+		} else {
+			counts[item.key()]--
+		}
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// diffItems returns the valid networks in their canonical form with the host bits cleared
+func diffItems(networks []*net.IPNet, isNew bool) []diffItem {
+	items := make([]diffItem, 0, len(networks))
+	for _, network := range networks {
+		if first, last, family := uint128Range(network); family != 0 && MaskIsContiguous(network.Mask) {
+			network = canonicalNetwork(network.IP.Mask(network.Mask), network.Mask)
+			items = append(items, diffItem{network, first, last, family, isNew})
+		}
+	}
+	return items
+}
+
+// sortDiffItems orders the items by family, first address and then largest network first
+func sortDiffItems(items []diffItem) {
+	slices.SortStableFunc(items, func(a, b diffItem) int {
+		switch {
+		case a.family != b.family:
+			return int(a.family) - int(b.family)
+		case a.first != b.first:
+			return a.first.cmp(b.first)
+		}
+		return b.last.cmp(a.last)
+	})
+}
+
+// networkOrder compares networks in the order of NetworkComesBefore
+func networkOrder(a, b *net.IPNet) int {
+	switch {
+	case NetworkComesBefore(a, b):
+		return -1
+	case NetworkComesBefore(b, a):
+		return 1
+	}
+	return 0
+}
+
+// newNetworkChange classifies a group of overlapping networks
+func newNetworkChange(items []diffItem) NetworkChange {
+	var change NetworkChange
+	var oldItems, newItems []diffItem
+	for _, item := range items {
+		if item.isNew {
+			change.New = append(change.New, item.network)
+			newItems = append(newItems, item)
+		} else {
+			change.Old = append(change.Old, item.network)
+			oldItems = append(oldItems, item)
+		}
+	}
+	change.AddressDelta = new(big.Int).Sub(unionSize(newItems), unionSize(oldItems))
+	switch {
+	case len(change.Old) == 0:
+		change.Kind = Added
+	case len(change.New) == 0:
+		change.Kind = Removed
+	case len(change.Old) == 1 && len(change.New) > 1 && containsAll(oldItems[0], newItems):
+		change.Kind = Split
+	case len(change.New) == 1 && len(change.Old) > 1 && containsAll(newItems[0], oldItems):
+		change.Kind = Merged
+	default:
+		change.Kind = Resized
+	}
+	return change
+}
+
+// containsAll reports whether the network is larger than each of the items and contains them
+func containsAll(network diffItem, items []diffItem) bool {
+	for _, item := range items {
+		if network.key() == item.key() || item.first.cmp(network.first) < 0 || item.last.cmp(network.last) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unionSize returns the number of addresses covered by the items
+func unionSize(items []diffItem) *big.Int {
+	sorted := append([]diffItem{}, items...)
+	sortDiffItems(sorted)
+	size := new(big.Int)
+	for start := 0; start < len(sorted); {
+		first, last := sorted[start].first, sorted[start].last
+		end := start + 1
+		for ; end < len(sorted) && sorted[end].family == sorted[start].family && sorted[end].first.cmp(last) <= 0; end++ {
+			if sorted[end].last.cmp(last) > 0 {
+				last = sorted[end].last
+			}
+		}
+		span := last.sub(first)
+		size.Add(size, new(big.Int).Lsh(new(big.Int).SetUint64(span.hi), 64))
+		size.Add(size, new(big.Int).SetUint64(span.lo))
+		size.Add(size, bigOne)
+		start = end
+	}
+	return size
+}
@@ -0,0 +1,134 @@
+package subnetmath
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		networks[i] = ParseNetworkCIDR(cidr)
+	}
+	return networks
+}
+
+func TestDiffNetworks(t *testing.T) {
+	oldNetworks := parseNetworks(
+		"2001:db8::/48", "10.0.0.0/16", "192.168.0.0/24", "192.168.1.0/24",
+		"172.16.0.0/24", "198.51.100.0/24", "203.0.113.0/25",
+	)
+	newNetworks := parseNetworks(
+		"10.0.0.0/17", "10.0.128.0/17", "192.168.0.0/23", "172.16.0.0/22",
+		"198.51.100.0/24", "100.64.0.0/10", "2001:db8::/48",
+	)
+	output := DiffNetworks(oldNetworks, newNetworks)
+	var actual []string
+	for _, change := range output.Changes {
+		actual = append(actual, change.String()+" "+change.AddressDelta.String())
+	}
+	expected := []string{
+		"split 10.0.0.0/16 -> 10.0.0.0/17 10.0.128.0/17 0",
+		"added 100.64.0.0/10 4194304",
+		"resized 172.16.0.0/24 -> 172.16.0.0/22 768",
+		"merged 192.168.0.0/24 192.168.1.0/24 -> 192.168.0.0/23 0",
+		"removed 203.0.113.0/25 -128",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") ||
+		!sliceOfSubnetsAreEqual(output.Unchanged, parseNetworks("198.51.100.0/24", "2001:db8::/48")) ||
+		output.AddressDelta.Int64() != 4194304+768-128 {
+		t.Error(
+			"\n<<<input>>>\n", oldNetworks, "\n", newNetworks,
+			"\n<<<actual_output>>>\n", strings.Join(actual, "\n"), "\n", output.Unchanged, output.AddressDelta,
+			"\n<<<expected_output>>>\n", strings.Join(expected, "\n"),
+		)
+	}
+}
+
+func TestDiffNetworksOverlapping(t *testing.T) {
+	oldNetworks := parseNetworks("10.0.0.0/24", "10.0.0.0/24", "10.0.1.0/24", "::/0")
+	newNetworks := parseNetworks("10.0.0.0/24", "10.0.0.128/25", "10.0.1.0/25", "10.0.1.128/26", "::/1")
+	output := DiffNetworks(oldNetworks, newNetworks)
+	var actual []string
+	for _, change := range output.Changes {
+		actual = append(actual, change.String()+" "+change.AddressDelta.String())
+	}
+	expected := []string{
+		"resized 10.0.0.0/24 10.0.0.0/24 -> 10.0.0.0/24 10.0.0.128/25 0",
+		"split 10.0.1.0/24 -> 10.0.1.0/25 10.0.1.128/26 -64",
+		"resized ::/0 -> ::/1 -170141183460469231731687303715884105728",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") || output.AddressDelta.String() != "-170141183460469231731687303715884105792" {
+		t.Error(
+			"\n<<<input>>>\n", oldNetworks, "\n", newNetworks,
+			"\n<<<actual_output>>>\n", strings.Join(actual, "\n"), "\n", output.AddressDelta,
+			"\n<<<expected_output>>>\n", strings.Join(expected, "\n"),
+		)
+	}
+}
+
+func TestDiffNetworksHostBits(t *testing.T) {
+	oldNetworks := []*net.IPNet{{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(24, 32)}}
+	newNetworks := []*net.IPNet{
+		{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(24, 32)},
+		{IP: net.ParseIP("192.0.1.1"), Mask: net.CIDRMask(16, 32)},
+		{IP: net.ParseIP("192.0.0.0"), Mask: net.CIDRMask(104, 128)},
+	}
+	output := DiffNetworks(oldNetworks, newNetworks)
+	var actual []string
+	for _, change := range output.Changes {
+		actual = append(actual, change.String())
+	}
+	// the IPv4-mapped /104 is a /8 so it comes before the /16
+	expected := []string{"added 192.0.0.0/8 192.0.0.0/16"}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") ||
+		!sliceOfSubnetsAreEqual(output.Unchanged, parseNetworks("10.0.0.0/24")) {
+		t.Error(
+			"\n<<<input>>>\n", oldNetworks, "\n", newNetworks,
+			"\n<<<actual_output>>>\n", strings.Join(actual, "\n"), "\n", output.Unchanged,
+			"\n<<<expected_output>>>\n", strings.Join(expected, "\n"),
+		)
+	}
+}
+
+func TestDiffNetworksIdenticalOverlap(t *testing.T) {
+	for _, test := range []struct {
+		oldNetworks, newNetworks []*net.IPNet
+		expected                 string
+		delta                    int64
+	}{
+		{
+			parseNetworks("10.0.0.0/16"),
+			parseNetworks("10.0.0.0/16", "10.0.1.0/24"),
+			"resized 10.0.0.0/16 -> 10.0.0.0/16 10.0.1.0/24 0",
+			0,
+		},
+		{
+			parseNetworks("10.0.0.0/16", "10.0.0.0/24"),
+			parseNetworks("10.0.0.0/24", "10.0.0.0/25", "10.0.0.128/25"),
+			"resized 10.0.0.0/16 10.0.0.0/24 -> 10.0.0.0/24 10.0.0.0/25 10.0.0.128/25 -65280",
+			-65280,
+		},
+		{
+			parseNetworks("10.0.0.0/16", "10.0.0.0/24"),
+			parseNetworks("10.0.0.0/24", "10.0.0.0/16"),
+			"",
+			0,
+		},
+	} {
+		output := DiffNetworks(test.oldNetworks, test.newNetworks)
+		var actual []string
+		for _, change := range output.Changes {
+			actual = append(actual, change.String()+" "+change.AddressDelta.String())
+		}
+		if strings.Join(actual, "\n") != test.expected || output.AddressDelta.Int64() != test.delta ||
+			(test.expected == "") != (len(output.Unchanged) == len(test.newNetworks)) {
+			t.Error(
+				"\n<<<input>>>\n", test.oldNetworks, "\n", test.newNetworks,
+				"\n<<<actual_output>>>\n", strings.Join(actual, "\n"), "\n", output.Unchanged, output.AddressDelta,
+				"\n<<<expected_output>>>\n", test.expected, test.delta,
+			)
+		}
+	}
+}
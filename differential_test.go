@@ -0,0 +1,501 @@
+package subnetmath
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+// The differential tests compare subnetmath against independent models built on net/netip
+// and, for small IPv4 aggregates, the bitset model of bruteForceUnused. Failures are shrunk
+// to a minimal counterexample before being reported. Reproduce a run with
+//
+//	go test -run TestDifferential -differential.seed=N
+
+var differentialSeed = flag.Int64("differential.seed", 1, "seed for the differential tests")
+var differentialIterations = flag.Int("differential.iterations", 400, "cases per family and property")
+
+// lowIPv6 holds the IPv4-mapped addresses which subnetmath deliberately treats as IPv4.
+// Generated IPv6 prefixes either contain all of it or stay clear of it.
+var lowIPv6 = netip.MustParsePrefix("::/64")
+
+func validDifferentialPrefix(p netip.Prefix) bool {
+	if !p.IsValid() || p.Addr().Is4() {
+		return p.IsValid()
+	}
+	return !lowIPv6.Overlaps(p) || p.Bits() <= lowIPv6.Bits() && p.Addr() == p.Masked().Addr()
+}
+
+// prefixGenerator produces random prefixes that are biased towards the edges of the space
+type prefixGenerator struct {
+	rng *rand.Rand
+}
+
+func (g prefixGenerator) addr(family Family) netip.Addr {
+	b := make([]byte, familyBits(family)/8)
+	switch g.rng.Intn(6) {
+	case 0:
+	case 1:
+		for i := range b {
+			b[i] = 0xff
+		}
+	case 2:
+		g.rng.Read(b)
+		for i := range b[:len(b)-1] {
+			b[i] = 0xff
+		}
+	default:
+		g.rng.Read(b)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+func (g prefixGenerator) bits(family Family) int {
+	maxBits := familyBits(family)
+	switch g.rng.Intn(8) {
+	case 0:
+		return 0
+	case 1:
+		return 1
+	case 2:
+		return maxBits - 1
+	case 3:
+		return maxBits
+	}
+	return g.rng.Intn(maxBits + 1)
+}
+
+func (g prefixGenerator) prefix(family Family) netip.Prefix {
+	for {
+		p := netip.PrefixFrom(g.addr(family), g.bits(family))
+		if g.rng.Intn(2) == 0 {
+			p = p.Masked()
+		}
+		if validDifferentialPrefix(p) {
+			return p
+		}
+	}
+}
+
+// subprefix returns a prefix that is usually within the aggregate so that they overlap
+func (g prefixGenerator) subprefix(aggregate netip.Prefix) netip.Prefix {
+	family := differentialFamily(aggregate)
+	for {
+		p := g.prefix(family)
+		if g.rng.Intn(4) > 0 {
+			b := aggregate.Masked().Addr().AsSlice()
+			random := p.Addr().AsSlice()
+			for i := aggregate.Bits(); i < len(b)*8; i++ {
+				b[i/8] |= random[i/8] & (0x80 >> uint(i%8))
+			}
+			addr, _ := netip.AddrFromSlice(b)
+			bits := aggregate.Bits() + g.rng.Intn(familyBits(family)-aggregate.Bits()+1)
+			p = netip.PrefixFrom(addr, bits).Masked()
+		}
+		if validDifferentialPrefix(p) {
+			return p
+		}
+	}
+}
+
+func differentialFamily(p netip.Prefix) Family {
+	if p.Addr().Is4() {
+		return IPv4
+	}
+	return IPv6
+}
+
+func toIPNet(p netip.Prefix) *net.IPNet {
+	return &net.IPNet{
+		IP:   net.IP(p.Addr().AsSlice()),
+		Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen()),
+	}
+}
+
+func toIP(addr netip.Addr) net.IP {
+	b := addr.As16()
+	return net.IP(b[:])
+}
+
+// toPrefixes converts canonical networks and reports those that aren't canonical
+func toPrefixes(networks []*net.IPNet) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		if network == nil {
+			return nil, fmt.Errorf("nil network in %v", networks)
+		}
+		ones, bits := network.Mask.Size()
+		addr, ok := netip.AddrFromSlice(network.IP)
+		if !ok || bits != len(network.IP)*8 {
+			return nil, fmt.Errorf("%v is not canonical", network)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, ones))
+	}
+	return prefixes, nil
+}
+
+func equalPrefixes(alpha, bravo []netip.Prefix) bool {
+	if len(alpha) != len(bravo) {
+		return false
+	}
+	for i := range alpha {
+		if alpha[i] != bravo[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNetworks returns a description of how the networks differ from the expected prefixes
+func compareNetworks(call string, networks []*net.IPNet, expected []netip.Prefix) string {
+	actual, err := toPrefixes(networks)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", call, err)
+	}
+	if !equalPrefixes(actual, expected) {
+		return fmt.Sprintf("%s = %v, want %v", call, actual, expected)
+	}
+	return ""
+}
+
+func compareAddr(call string, actual net.IP, expected netip.Addr) string {
+	switch {
+	case !expected.IsValid() && actual != nil:
+		return fmt.Sprintf("%s = %v, want nil", call, actual)
+	case expected.IsValid() && (len(actual) != net.IPv6len || !actual.Equal(toIP(expected))):
+		return fmt.Sprintf("%s = %v, want %v", call, actual, expected)
+	}
+	return ""
+}
+
+// lastAddr returns the last address of the prefix
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> uint(i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// halves splits a prefix into its two subnets which must not be a host prefix
+func halves(p netip.Prefix) (netip.Prefix, netip.Prefix) {
+	b := p.Masked().Addr().AsSlice()
+	b[p.Bits()/8] |= 0x80 >> uint(p.Bits()%8)
+	upper, _ := netip.AddrFromSlice(b)
+	return netip.PrefixFrom(p.Masked().Addr(), p.Bits()+1), netip.PrefixFrom(upper, p.Bits()+1)
+}
+
+// coverRange recursively splits the prefix until each part is within or outside of the range
+func coverRange(p netip.Prefix, start, stop netip.Addr, covered []netip.Prefix) []netip.Prefix {
+	first, last := p.Masked().Addr(), lastAddr(p)
+	switch {
+	case last.Less(start) || stop.Less(first):
+		return covered
+	case !first.Less(start) && !stop.Less(last) && p.Bits() > 0:
+		return append(covered, p.Masked())
+	}
+	lower, upper := halves(p)
+	return coverRange(upper, start, stop, coverRange(lower, start, stop, covered))
+}
+
+// referenceInbetween is the netip model of FindInbetweenSubnets
+func referenceInbetween(start, stop netip.Addr) []netip.Prefix {
+	if !start.Less(stop) {
+		return nil
+	}
+	return coverRange(netip.PrefixFrom(start, 0).Masked(), start, stop, nil)
+}
+
+// referenceUnused is the netip model of FindUnusedSubnets which recursively splits the
+// aggregate until each part is either free or entirely used
+func referenceUnused(p netip.Prefix, used []netip.Prefix, unused []netip.Prefix) []netip.Prefix {
+	overlapped := false
+	for _, u := range used {
+		switch {
+		case u.Addr().Is4() != p.Addr().Is4() || !u.Overlaps(p):
+		case u.Bits() <= p.Bits():
+			return unused
+		default:
+			overlapped = true
+		}
+	}
+	if !overlapped {
+		return append(unused, p.Masked())
+	}
+	lower, upper := halves(p)
+	return referenceUnused(upper, used, referenceUnused(lower, used, unused))
+}
+
+// differentialProperty compares subnetmath with a model for the generated prefixes and
+// returns a description of the first disagreement
+type differentialProperty struct {
+	name     string
+	minLen   int
+	generate func(g prefixGenerator, family Family) []netip.Prefix
+	check    func(prefixes []netip.Prefix) string
+}
+
+func generatePrefixes(count int) func(prefixGenerator, Family) []netip.Prefix {
+	return func(g prefixGenerator, family Family) []netip.Prefix {
+		prefixes := make([]netip.Prefix, count)
+		for i := range prefixes {
+			prefixes[i] = g.prefix(family)
+		}
+		return prefixes
+	}
+}
+
+var differentialProperties = []differentialProperty{
+	{
+		name:     "Addresses",
+		minLen:   1,
+		generate: generatePrefixes(1),
+		check: func(prefixes []netip.Prefix) string {
+			addr := prefixes[0].Addr()
+			family := differentialFamily(prefixes[0])
+			expected := new(big.Int).SetBytes(addr.AsSlice())
+			if actual := AddrToInt(toIP(addr)); actual.Cmp(expected) != 0 {
+				return fmt.Sprintf("AddrToInt(%v) = %v, want %v", addr, actual, expected)
+			}
+			if failure := compareAddr(fmt.Sprintf("IntToAddrFamily(%v, %v)", expected, family),
+				IntToAddrFamily(expected, family), addr); failure != "" {
+				return failure
+			}
+			return compareAddr(fmt.Sprintf("NextAddr(%v)", addr), NextAddr(toIP(addr)), addr.Next())
+		},
+	},
+	{
+		name:     "Networks",
+		minLen:   1,
+		generate: generatePrefixes(1),
+		check: func(prefixes []netip.Prefix) string {
+			p := prefixes[0]
+			network := toIPNet(p.Masked())
+			call := func(name string) string { return fmt.Sprintf("%s(%v)", name, p.Masked()) }
+			if failure := compareAddr(fmt.Sprintf("SubnetZeroAddr(%v, %v)", p.Addr(), p.Masked()),
+				SubnetZeroAddr(toIP(p.Addr()), network), p.Masked().Addr()); failure != "" {
+				return failure
+			}
+			if failure := compareAddr(call("BroadcastAddr"), BroadcastAddr(network), lastAddr(p)); failure != "" {
+				return failure
+			}
+			var expected []netip.Prefix
+			if next := lastAddr(p).Next(); next.IsValid() {
+				expected = []netip.Prefix{netip.PrefixFrom(next, p.Bits())}
+			}
+			var actual []*net.IPNet
+			if next := NextNetwork(network); next != nil {
+				actual = append(actual, next)
+			}
+			if failure := compareNetworks(call("NextNetwork"), actual, expected); failure != "" {
+				return failure
+			}
+			expected, actual = nil, nil
+			if p.Bits() < p.Addr().BitLen() {
+				expected = []netip.Prefix{netip.PrefixFrom(p.Masked().Addr(), p.Bits()+1)}
+			}
+			if shrunk := ShrinkNetwork(network); shrunk != nil {
+				actual = append(actual, shrunk)
+			}
+			return compareNetworks(call("ShrinkNetwork"), actual, expected)
+		},
+	},
+	{
+		name:     "Ordering",
+		minLen:   2,
+		generate: generatePrefixes(2),
+		check: func(prefixes []netip.Prefix) string {
+			alpha, bravo := prefixes[0].Masked(), prefixes[1].Masked()
+			expected := alpha.Bits() <= bravo.Bits() && alpha.Contains(bravo.Addr())
+			if actual := NetworkContainsSubnet(toIPNet(alpha), toIPNet(bravo)); actual != expected {
+				return fmt.Sprintf("NetworkContainsSubnet(%v, %v) = %v, want %v", alpha, bravo, actual, expected)
+			}
+			expected = alpha.Addr().Less(bravo.Addr()) || alpha.Addr() == bravo.Addr() && alpha.Bits() < bravo.Bits()
+			if actual := NetworkComesBefore(toIPNet(alpha), toIPNet(bravo)); actual != expected {
+				return fmt.Sprintf("NetworkComesBefore(%v, %v) = %v, want %v", alpha, bravo, actual, expected)
+			}
+			expected = alpha == bravo
+			if actual := NetworksAreIdentical(toIPNet(alpha), toIPNet(bravo)); actual != expected {
+				return fmt.Sprintf("NetworksAreIdentical(%v, %v) = %v, want %v", alpha, bravo, actual, expected)
+			}
+			return ""
+		},
+	},
+	{
+		name:     "FindInbetweenSubnets",
+		minLen:   2,
+		generate: generatePrefixes(2),
+		check: func(prefixes []netip.Prefix) string {
+			start, stop := prefixes[0].Addr(), prefixes[1].Addr()
+			expected := referenceInbetween(start, stop)
+			call := fmt.Sprintf("(%v, %v)", start, stop)
+			for name, actual := range map[string][]*net.IPNet{
+				"FindInbetweenSubnets":        FindInbetweenSubnets(toIP(start), toIP(stop)),
+				"Buffer.FindInbetweenSubnets": NewBuffer().FindInbetweenSubnets(toIP(start), toIP(stop)),
+				"AppendInbetweenSubnets":      AppendInbetweenSubnets(nil, toIP(start), toIP(stop)),
+			} {
+				if failure := compareNetworks(name+call, actual, expected); failure != "" {
+					return failure
+				}
+			}
+			return ""
+		},
+	},
+	{
+		name:   "FindUnusedSubnets",
+		minLen: 1,
+		generate: func(g prefixGenerator, family Family) []netip.Prefix {
+			prefixes := []netip.Prefix{g.prefix(family).Masked()}
+			for i := g.rng.Intn(5); i > 0; i-- {
+				prefixes = append(prefixes, g.subprefix(prefixes[0]))
+			}
+			return prefixes
+		},
+		check: func(prefixes []netip.Prefix) string {
+			aggregate := toIPNet(prefixes[0].Masked())
+			var used []netip.Prefix
+			var subnets []*net.IPNet
+			for _, p := range prefixes[1:] {
+				used = append(used, p.Masked())
+				subnets = append(subnets, toIPNet(p.Masked()))
+			}
+			expected := referenceUnused(prefixes[0], used, nil)
+			call := fmt.Sprintf("(%v, %v)", prefixes[0].Masked(), used)
+			outputs := map[string][]*net.IPNet{
+				"FindUnusedSubnets":        FindUnusedSubnets(aggregate, subnets...),
+				"Buffer.FindUnusedSubnets": NewBuffer().FindUnusedSubnets(aggregate, subnets...),
+				"AppendUnusedSubnets":      AppendUnusedSubnets(nil, aggregate, subnets...),
+			}
+			if prefixes[0].Addr().Is4() && prefixes[0].Bits() >= 16 {
+				outputs["bruteForceUnused"] = bruteForceUnused(aggregate, subnets)
+			}
+			for name, actual := range outputs {
+				if failure := compareNetworks(name+call, actual, expected); failure != "" {
+					return failure
+				}
+			}
+			return ""
+		},
+	},
+}
+
+// shrinkCandidates returns simpler variations of the prefixes. Each candidate removes a
+// prefix, shortens one or clears a single bit of its address so that shrinking terminates.
+func shrinkCandidates(prefixes []netip.Prefix, minLen int) [][]netip.Prefix {
+	var candidates [][]netip.Prefix
+	replace := func(i int, p netip.Prefix) {
+		if validDifferentialPrefix(p) && p != prefixes[i] {
+			candidate := append([]netip.Prefix{}, prefixes...)
+			candidate[i] = p
+			candidates = append(candidates, candidate)
+		}
+	}
+	for i, p := range prefixes {
+		if len(prefixes) > minLen {
+			candidates = append(candidates, append(append([]netip.Prefix{}, prefixes[:i]...), prefixes[i+1:]...))
+		}
+		replace(i, p.Masked())
+		if p.Bits() > 0 {
+			replace(i, netip.PrefixFrom(p.Addr(), p.Bits()-1))
+		}
+		b := p.Addr().AsSlice()
+		for bit := 0; bit < len(b)*8; bit++ {
+			if b[bit/8]&(0x80>>uint(bit%8)) != 0 {
+				cleared := append([]byte{}, b...)
+				cleared[bit/8] &^= 0x80 >> uint(bit%8)
+				addr, _ := netip.AddrFromSlice(cleared)
+				replace(i, netip.PrefixFrom(addr, p.Bits()))
+			}
+		}
+	}
+	return candidates
+}
+
+// shrink repeatedly replaces the failing prefixes with the first failing candidate until
+// none of the candidates fail
+func shrink(prefixes []netip.Prefix, minLen int, fails func([]netip.Prefix) bool) []netip.Prefix {
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for _, candidate := range shrinkCandidates(prefixes, minLen) {
+			if fails(candidate) {
+				prefixes, shrunk = candidate, true
+				break
+			}
+		}
+	}
+	return prefixes
+}
+
+func TestDifferential(t *testing.T) {
+	iterations := *differentialIterations
+	if testing.Short() {
+		iterations /= 10
+	}
+	for _, property := range differentialProperties {
+		t.Run(property.name, func(t *testing.T) {
+			g := prefixGenerator{rand.New(rand.NewSource(*differentialSeed))}
+			for _, family := range []Family{IPv4, IPv6} {
+				for i := 0; i < iterations; i++ {
+					prefixes := property.generate(g, family)
+					if failure := property.check(prefixes); failure != "" {
+						minimal := shrink(prefixes, property.minLen, func(candidate []netip.Prefix) bool {
+							return property.check(candidate) != ""
+						})
+						t.Fatalf("seed %d case %d: %s\nminimal counterexample %v: %s",
+							*differentialSeed, i, failure, minimal, property.check(minimal))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDifferentialEdges(t *testing.T) {
+	edges := []string{
+		"0.0.0.0/0", "0.0.0.0/32", "255.255.255.255/32", "255.255.255.254/31", "128.0.0.0/1",
+		"::/0", "::/64", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128", "8000::/1", "1::/128",
+	}
+	for _, property := range differentialProperties {
+		for _, alpha := range edges {
+			for _, bravo := range edges {
+				prefixes := []netip.Prefix{netip.MustParsePrefix(alpha), netip.MustParsePrefix(bravo)}
+				if prefixes[0].Addr().Is4() != prefixes[1].Addr().Is4() {
+					continue
+				}
+				if failure := property.check(prefixes[:property.minLen]); failure != "" {
+					t.Errorf("%s: %s", property.name, failure)
+				}
+				if failure := property.check(prefixes); failure != "" {
+					t.Errorf("%s: %s", property.name, failure)
+				}
+			}
+		}
+	}
+}
+
+func TestDifferentialShrink(t *testing.T) {
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("10.1.0.0/16"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+	}
+	target := netip.MustParseAddr("10.1.2.3")
+	minimal := shrink(prefixes, 1, func(candidate []netip.Prefix) bool {
+		for _, p := range candidate {
+			if p.Contains(target) {
+				return true
+			}
+		}
+		return false
+	})
+	expected := []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")}
+	if !equalPrefixes(minimal, expected) {
+		t.Errorf("<<<input>>>\n%v\n<<<actual_output>>>\n%v\n<<<expected_output>>>\n%v", prefixes, minimal, expected)
+	}
+}
@@ -0,0 +1,244 @@
+package subnetmath
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// checkSubnets verifies that the subnets are canonical, aligned, sorted, disjoint and
+// contiguous so that together they cover exactly the inclusive range from first to last
+func checkSubnets(t *testing.T, subnets []*net.IPNet, first, last uint128) {
+	t.Helper()
+	var previousLast uint128
+	for i, subnet := range subnets {
+		subnetFirst, subnetLast, family := uint128Range(subnet)
+		switch {
+		case family == 0 || len(subnet.IP) != len(subnet.Mask):
+			t.Fatalf("subnet %d %v is not canonical", i, subnet)
+		case !subnet.IP.Equal(subnet.IP.Mask(subnet.Mask)):
+			t.Fatalf("subnet %d %v is not aligned", i, subnet)
+		case i == 0 && subnetFirst != first:
+			t.Fatalf("subnet %d %v does not start the range", i, subnet)
+		case i > 0 && !NetworkComesBefore(subnets[i-1], subnet):
+			t.Fatalf("subnet %d %v is not sorted after %v", i, subnet, subnets[i-1])
+		case i > 0 && subnetFirst != previousLast.add1():
+			t.Fatalf("subnet %d %v is not adjacent to %v", i, subnet, subnets[i-1])
+		}
+		previousLast = subnetLast
+	}
+	if len(subnets) > 0 && previousLast != last {
+		t.Fatalf("subnets %v do not end the range", subnets)
+	}
+}
+
+func (u uint128) add1() uint128 {
+	sum, _ := u.add(uint128{0, 1})
+	return sum
+}
+
+// bruteForceUnused is a reference implementation of FindUnusedSubnets for IPv4 aggregates
+// of /16 or longer. Every address is marked in a bitset and the free addresses are then
+// greedily grouped into the largest aligned subnets.
+func bruteForceUnused(aggregate *net.IPNet, subnets []*net.IPNet) []*net.IPNet {
+	first, last, _ := uint128Range(aggregate)
+	size := int(last.lo - first.lo + 1)
+	used := make([]bool, size)
+	for _, subnet := range subnets {
+		subnetFirst, subnetLast, family := uint128Range(subnet)
+		if family != IPv4 {
+			continue
+		}
+		for addr := max(subnetFirst.lo, first.lo); addr <= min(subnetLast.lo, last.lo); addr++ {
+			used[addr-first.lo] = true
+		}
+	}
+	var unused []*net.IPNet
+	for offset := 0; offset < size; {
+		if used[offset] {
+			offset++
+			continue
+		}
+		hostBits := 0
+		for next := hostBits + 1; ; next++ {
+			blockSize := 1 << uint(next)
+			if (int(first.lo)+offset)%blockSize != 0 || offset+blockSize > size {
+				break
+			}
+			free := true
+			for i := offset; i < offset+blockSize && free; i++ {
+				free = !used[i]
+			}
+			if !free {
+				break
+			}
+			hostBits = next
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, uint32(first.lo)+uint32(offset))
+		unused = append(unused, &net.IPNet{IP: ip, Mask: net.CIDRMask(32-hostBits, 32)})
+		offset += 1 << uint(hostBits)
+	}
+	return unused
+}
+
+// checkBuffer verifies that the Buffer methods which use its scratch space agree with the
+// package functions
+func checkBuffer(t *testing.T, buf *Buffer, network, subnet *net.IPNet) {
+	t.Helper()
+	switch {
+	case buf.NetworkContainsSubnet(network, subnet) != NetworkContainsSubnet(network, subnet):
+		t.Fatalf("Buffer.NetworkContainsSubnet disagrees for %v %v", network, subnet)
+	case !buf.AppendSubnetZeroAddr(nil, subnet.IP, network).Equal(SubnetZeroAddr(subnet.IP, network)):
+		t.Fatalf("Buffer.AppendSubnetZeroAddr disagrees for %v %v", subnet.IP, network)
+	case !buf.AppendBroadcastAddr(nil, subnet).Equal(BroadcastAddr(subnet)):
+		t.Fatalf("Buffer.AppendBroadcastAddr disagrees for %v", subnet)
+	case !buf.AppendNextAddr(nil, subnet.IP).Equal(NextAddr(subnet.IP)):
+		t.Fatalf("Buffer.AppendNextAddr disagrees for %v", subnet.IP)
+	}
+}
+
+// smallIPv4Network returns a network within 10.0.0.0/20 so that brute force remains cheap
+func smallIPv4Network(offset uint16, ones uint8) *net.IPNet {
+	length := 20 + int(ones)%13
+	ip := net.IP{10, 0, byte(offset >> 8 & 0x0f), byte(offset)}
+	mask := net.CIDRMask(length, 32)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+func FuzzParseNetworkCIDR(f *testing.F) {
+	for _, seed := range []string{"192.168.0.0/23", "192.168.1.0/23", "::/0", "2001:db8::/32",
+		"::ffff:10.0.0.0/104", "0.0.0.0/0", "255.255.255.255/32", "1.2.3.4", "", "/24"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, cidr string) {
+		network := ParseNetworkCIDR(cidr)
+		strict, err := ParseNetworkCIDRStrict(cidr)
+		if (network == nil) != (err != nil) || network != nil && !NetworksAreIdentical(network, strict) {
+			t.Fatalf("ParseNetworkCIDR and ParseNetworkCIDRStrict disagree on %q", cidr)
+		}
+		if network == nil {
+			return
+		}
+		if err := ValidateNetwork(network); err != nil {
+			t.Fatalf("parsed network %v is invalid: %v", network, err)
+		}
+		if roundTrip := ParseNetworkCIDR(network.String()); !NetworksAreIdentical(roundTrip, network) {
+			t.Fatalf("%v did not round trip, got %v", network, roundTrip)
+		}
+	})
+}
+
+func FuzzAddrToInt(f *testing.F) {
+	f.Add([]byte{192, 168, 1, 2})
+	f.Add([]byte{0, 0, 0, 0})
+	f.Add([]byte(net.ParseIP("::1")))
+	f.Add([]byte(net.ParseIP("::ffff:1.2.3.4")))
+	f.Add([]byte(net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		address := net.IP(b)
+		family := AddrFamily(address)
+		if family == 0 {
+			return
+		}
+		roundTrip := IntToAddrFamily(AddrToInt(address), family)
+		if len(roundTrip) != net.IPv6len || !roundTrip.Equal(address) {
+			t.Fatalf("%v did not round trip, got %v", address, roundTrip)
+		}
+		buffered := NewBuffer().IntToAddrFamily(NewBuffer().AddrToInt(address), family)
+		if !buffered.Equal(address) {
+			t.Fatalf("%v did not round trip through a Buffer, got %v", address, buffered)
+		}
+	})
+}
+
+func FuzzFindInbetweenSubnets(f *testing.F) {
+	f.Add([]byte{192, 168, 1, 2}, []byte{192, 168, 2, 2})
+	f.Add([]byte{0, 0, 0, 0}, []byte{255, 255, 255, 255})
+	f.Add([]byte{10, 0, 0, 1}, []byte{10, 0, 0, 1})
+	f.Add([]byte{0x3f, 0x33, 0x30}, []byte(net.ParseIP("3030:3030:3030:3030:3030:3030:3030:3030")))
+	f.Add([]byte(net.ParseIP("2001:400::")), []byte(net.ParseIP("2001:440:ffff:ffff:7fff:ffff:ffff:ffff")))
+	f.Add([]byte(net.ParseIP("::")), []byte(net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")))
+	f.Fuzz(func(t *testing.T, startBytes, stopBytes []byte) {
+		start, stop := net.IP(startBytes), net.IP(stopBytes)
+		first, startFamily := uint128FromAddr(start)
+		last, stopFamily := uint128FromAddr(stop)
+		expected := FindInbetweenSubnets(start, stop)
+		if startFamily == 0 || startFamily != stopFamily || first.cmp(last) >= 0 {
+			if len(expected) != 0 {
+				t.Fatalf("expected no subnets between %v and %v but got %v", start, stop, expected)
+			}
+			return
+		}
+		checkSubnets(t, expected, first, last)
+		var iterated []*net.IPNet
+		for subnet := range InbetweenSubnets(start, stop) {
+			iterated = append(iterated, subnet)
+		}
+		strict, err := FindInbetweenSubnetsStrict(start, stop)
+		for name, output := range map[string][]*net.IPNet{
+			"AppendInbetweenSubnets": AppendInbetweenSubnets(nil, start, stop),
+			"InbetweenSubnets":       iterated,
+			"Strict":                 strict,
+		} {
+			if !sliceOfSubnetsAreEqual(output, expected) {
+				t.Fatalf("%s disagrees between %v and %v: %v != %v", name, start, stop, output, expected)
+			}
+		}
+		if err != nil {
+			t.Fatalf("unexpected error between %v and %v: %v", start, stop, err)
+		}
+		buf := NewBuffer()
+		for _, subnet := range expected {
+			checkBuffer(t, buf, expected[0], subnet)
+			checkBuffer(t, buf, subnet, expected[len(expected)-1])
+		}
+	})
+}
+
+func FuzzFindUnusedSubnets(f *testing.F) {
+	f.Add(uint16(0), uint8(2), []byte{1, 0, 4, 2, 32, 10})
+	f.Add(uint16(0), uint8(0), []byte{})
+	f.Add(uint16(0x100), uint8(4), []byte{0, 0, 0})
+	f.Add(uint16(0xfff), uint8(12), []byte{0xff, 0x0f, 12})
+	f.Fuzz(func(t *testing.T, offset uint16, ones uint8, usedBytes []byte) {
+		aggregate := smallIPv4Network(offset, ones)
+		var subnets []*net.IPNet
+		for i := 0; i+2 < len(usedBytes) && len(subnets) < 16; i += 3 {
+			subnetOffset := uint16(usedBytes[i])<<8 | uint16(usedBytes[i+1])
+			subnets = append(subnets, smallIPv4Network(subnetOffset, usedBytes[i+2]))
+		}
+		expected := bruteForceUnused(aggregate, subnets)
+		var iterated []*net.IPNet
+		for subnet := range UnusedSubnets(aggregate, subnets...) {
+			iterated = append(iterated, subnet)
+		}
+		strict, err := FindUnusedSubnetsStrict(aggregate, subnets...)
+		if err != nil {
+			t.Fatalf("unexpected error for %v %v: %v", aggregate, subnets, err)
+		}
+		for name, output := range map[string][]*net.IPNet{
+			"FindUnusedSubnets":   FindUnusedSubnets(aggregate, subnets...),
+			"AppendUnusedSubnets": AppendUnusedSubnets(nil, aggregate, subnets...),
+			"UnusedSubnets":       iterated,
+			"Strict":              strict,
+		} {
+			if !sliceOfSubnetsAreEqual(output, expected) {
+				t.Fatalf("%s disagrees for %v %v: %v != %v", name, aggregate, subnets, output, expected)
+			}
+		}
+		buf := NewBuffer()
+		for i, subnet := range expected {
+			for _, used := range subnets {
+				if NetworkContainsSubnet(used, subnet) || NetworkContainsSubnet(subnet, used) {
+					t.Fatalf("unused subnet %d %v overlaps %v", i, subnet, used)
+				}
+				checkBuffer(t, buf, used, subnet)
+			}
+			if !NetworkContainsSubnet(aggregate, subnet) {
+				t.Fatalf("unused subnet %d %v is outside of %v", i, subnet, aggregate)
+			}
+			checkBuffer(t, buf, aggregate, subnet)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+// Package heatmap lays the cells of an IPv4 aggregate out on a grid along with how much
+// of each cell is used, such as for rendering the utilization of an address plan.
+package heatmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/demskie/subnetmath"
+)
+
+// Layout decides where each cell of the aggregate is placed on the grid
+type Layout int
+
+// supported layouts
+const (
+	// RowMajor places the cells in address order from left to right and then top to bottom
+	RowMajor Layout = iota
+	// Hilbert places the cells along a Hilbert curve so that cells with nearby addresses
+	// are nearby on the grid and every aligned subnet covers a compact area
+	Hilbert
+)
+
+func (l Layout) String() string {
+	switch l {
+	case RowMajor:
+		return "row-major"
+	case Hilbert:
+		return "hilbert"
+	}
+	return "unknown"
+}
+
+// MaxCellBits is the largest difference between the cell and aggregate prefix lengths,
+// which limits a grid to 1024×1024 cells
+const MaxCellBits = 20
+
+// errors returned by New
+var (
+	ErrNotIPv4           = errors.New("heatmap: aggregate is not IPv4")
+	ErrInvalidCellLength = errors.New("heatmap: invalid cell prefix length")
+)
+
+// Cell is a subnet of the aggregate and its position on the grid
+type Cell struct {
+	Network *net.IPNet
+	X, Y    int
+	// Utilization is the fraction of the addresses of the cell that are used, from 0 to 1
+	Utilization float64
+}
+
+// Grid is the utilization of an aggregate divided into cells of equal size
+type Grid struct {
+	Aggregate *net.IPNet
+	Layout    Layout
+	Width     int
+	Height    int
+	// Cells are in address order
+	Cells []Cell
+	// positions holds the index within Cells of each position in row-major order
+	positions []int
+}
+
+// New divides the IPv4 aggregate into subnets with the cell prefix length and computes the
+// utilization of each from the free space that FindUnusedSubnets leaves between the used
+// subnets. A /16 with /32 cells is a 256×256 grid. Grids with an odd number of cell bits are
+// twice as wide as they are high, which the Hilbert layout doesn't support. A
+// *subnetmath.ValidationError is returned for invalid networks, see FindUnusedSubnetsStrict.
+func New(aggregate *net.IPNet, cellPrefixLength int, layout Layout, used ...*net.IPNet) (*Grid, error) {
+	free, err := subnetmath.FindUnusedSubnetsStrict(aggregate, used...)
+	if err != nil {
+		return nil, err
+	}
+	if subnetmath.AddrFamily(aggregate.IP) != subnetmath.IPv4 {
+		return nil, ErrNotIPv4
+	}
+	ones := ipv4Ones(aggregate.Mask)
+	cellBits := cellPrefixLength - ones
+	if cellBits < 0 || cellBits > MaxCellBits || cellPrefixLength > 32 {
+		return nil, fmt.Errorf("%w: /%d cells of a /%d allow /%d to /%d", ErrInvalidCellLength,
+			cellPrefixLength, ones, ones, min(ones+MaxCellBits, 32))
+	}
+	if layout == Hilbert && cellBits%2 != 0 {
+		return nil, fmt.Errorf("%w: a Hilbert layout needs an even number of cell bits", ErrInvalidCellLength)
+	}
+	g := &Grid{
+		Aggregate: subnetmath.DuplicateNetwork(aggregate),
+		Layout:    layout,
+		Width:     1 << ((cellBits + 1) / 2),
+		Height:    1 << (cellBits / 2),
+		Cells:     make([]Cell, 1<<cellBits),
+	}
+	g.positions = make([]int, len(g.Cells))
+	base := uint64(ipv4ToUint32(aggregate.IP))
+	shift := uint(32 - cellPrefixLength)
+	cellSize := uint64(1) << shift
+	cellMask := net.CIDRMask(cellPrefixLength, 32)
+	freeCounts := make([]uint64, len(g.Cells))
+	for _, subnet := range free {
+		first := uint64(ipv4ToUint32(subnet.IP)) - base
+		last := first + uint64(1)<<(32-ipv4Ones(subnet.Mask)) - 1
+		for i := first >> shift; i <= last>>shift; i++ {
+			freeCounts[i] += min(last, (i+1)*cellSize-1) - max(first, i*cellSize) + 1
+		}
+	}
+	for i := range g.Cells {
+		x, y := i%g.Width, i/g.Width
+		if layout == Hilbert {
+			x, y = hilbertPoint(g.Width, i)
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, uint32(base+uint64(i)<<shift))
+		g.Cells[i] = Cell{
+			Network:     &net.IPNet{IP: ip, Mask: cellMask},
+			X:           x,
+			Y:           y,
+			Utilization: 1 - float64(freeCounts[i])/float64(cellSize),
+		}
+		g.positions[y*g.Width+x] = i
+	}
+	return g, nil
+}
+
+// At returns the cell at the position on the grid
+func (g *Grid) At(x, y int) Cell {
+	return g.Cells[g.positions[y*g.Width+x]]
+}
+
+// Utilization returns the fraction of the addresses of the aggregate that are used
+func (g *Grid) Utilization() float64 {
+	var total float64
+	for _, cell := range g.Cells {
+		total += cell.Utilization
+	}
+	return total / float64(len(g.Cells))
+}
+
+func ipv4ToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+// ipv4Ones returns the prefix length of an IPv4 mask that may be 16 bytes long
+func ipv4Ones(mask net.IPMask) int {
+	ones, _ := mask[len(mask)-net.IPv4len:].Size()
+	return ones
+}
+
+// hilbertPoint returns the position of the distance along a Hilbert curve filling a square
+// with the side, which is a power of two
+func hilbertPoint(side, distance int) (x, y int) {
+	for s := 1; s < side; s *= 2 {
+		rx := 1 & (distance / 2)
+		ry := 1 & (distance ^ rx)
+		if ry == 0 {
+			if rx == 1 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+		x += s * rx
+		y += s * ry
+		distance /= 4
+	}
+	return x, y
+}
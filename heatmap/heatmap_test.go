@@ -0,0 +1,150 @@
+package heatmap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/demskie/subnetmath"
+)
+
+func TestRowMajor(t *testing.T) {
+	aggregate := subnetmath.ParseNetworkCIDR("10.0.0.0/24")
+	used := []*net.IPNet{
+		subnetmath.ParseNetworkCIDR("10.0.0.0/26"),
+		subnetmath.ParseNetworkCIDR("10.0.0.64/27"),
+		subnetmath.ParseNetworkCIDR("10.0.0.255/32"),
+	}
+	grid, err := New(aggregate, 26, RowMajor, used...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output []string
+	for _, cell := range grid.Cells {
+		output = append(output, fmt.Sprint(cell.Network, cell.X, cell.Y, cell.Utilization, grid.At(cell.X, cell.Y).Network))
+	}
+	expected := []string{
+		"10.0.0.0/26 0 0 1 10.0.0.0/26",
+		"10.0.0.64/26 1 0 0.5 10.0.0.64/26",
+		"10.0.0.128/26 0 1 0 10.0.0.128/26",
+		"10.0.0.192/26 1 1 0.015625 10.0.0.192/26",
+	}
+	if grid.Width != 2 || grid.Height != 2 || strings.Join(output, "\n") != strings.Join(expected, "\n") {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, used,
+			"\n<<<actual_output>>>\n", grid.Width, grid.Height, output,
+			"\n<<<expected_output>>>\n", 2, 2, expected,
+		)
+	}
+}
+
+func TestHilbert(t *testing.T) {
+	aggregate := subnetmath.ParseNetworkCIDR("10.0.0.0/8")
+	used := subnetmath.ParseNetworkCIDR("10.64.0.0/10")
+	grid, err := New(aggregate, 16, Hilbert, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[[2]int]bool{}
+	for i, cell := range grid.Cells {
+		seen[[2]int{cell.X, cell.Y}] = true
+		if i > 0 {
+			previous := grid.Cells[i-1]
+			if distance := abs(cell.X-previous.X) + abs(cell.Y-previous.Y); distance != 1 {
+				t.Fatal(
+					"\n<<<input>>>\n", previous.Network, cell.Network,
+					"\n<<<actual_output>>>\n", distance,
+					"\n<<<expected_output>>>\n", 1,
+				)
+			}
+		}
+		// the used /10 is the second quarter of the curve which fills the lower left quadrant
+		inQuadrant := cell.X < 8 && cell.Y >= 8
+		if (cell.Utilization == 1) != inQuadrant || grid.At(cell.X, cell.Y).Network != cell.Network {
+			t.Fatal(
+				"\n<<<input>>>\n", cell.Network,
+				"\n<<<actual_output>>>\n", cell.X, cell.Y, cell.Utilization,
+				"\n<<<expected_output>>>\n", inQuadrant,
+			)
+		}
+	}
+	if len(seen) != 256 || grid.Utilization() != 0.25 {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, used,
+			"\n<<<actual_output>>>\n", len(seen), grid.Utilization(),
+			"\n<<<expected_output>>>\n", 256, 0.25,
+		)
+	}
+}
+
+func abs(i int) int {
+	return max(i, -i)
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, test := range []struct {
+		aggregate string
+		cells     int
+		layout    Layout
+		expected  error
+	}{
+		{"2001:db8::/32", 48, RowMajor, ErrNotIPv4},
+		{"10.0.0.0/8", 7, RowMajor, ErrInvalidCellLength},
+		{"10.0.0.0/8", 29, RowMajor, ErrInvalidCellLength},
+		{"10.0.0.0/30", 33, RowMajor, ErrInvalidCellLength},
+		{"10.0.0.0/8", 15, Hilbert, ErrInvalidCellLength},
+		{"", 16, RowMajor, subnetmath.ErrNilNetwork},
+	} {
+		_, err := New(subnetmath.ParseNetworkCIDR(test.aggregate), test.cells, test.layout)
+		if !errors.Is(err, test.expected) {
+			t.Error(
+				"\n<<<input>>>\n", test.aggregate, test.cells, test.layout,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	grid, err := New(subnetmath.ParseNetworkCIDR("192.168.0.0/16"), 21, RowMajor, subnetmath.ParseNetworkCIDR("192.168.0.0/18"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var svg, encoded bytes.Buffer
+	if err := grid.WriteSVG(&svg, 10); err != nil {
+		t.Fatal(err)
+	}
+	if rects := strings.Count(svg.String(), "<rect "); rects != 32 ||
+		!strings.Contains(svg.String(), `width="80" height="40"`) ||
+		!strings.Contains(svg.String(), "<title>192.168.0.0/16 25.00% used</title>") {
+		t.Error(
+			"\n<<<input>>>\n", grid.Aggregate,
+			"\n<<<actual_output>>>\n", svg.String(),
+			"\n<<<expected_output>>>\n", "an 80x40 SVG with 32 rects",
+		)
+	}
+	if err := grid.WritePNG(&encoded, 3); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&encoded)
+	if err != nil || img.Bounds().Dx() != 24 || img.Bounds().Dy() != 12 {
+		t.Fatal(
+			"\n<<<input>>>\n", grid.Aggregate,
+			"\n<<<actual_output>>>\n", img, err,
+			"\n<<<expected_output>>>\n", "a 24x12 PNG",
+		)
+	}
+	r, g, b, _ := img.At(0, 0).RGBA()
+	if used := Color(1); uint8(r>>8) != used.R || uint8(g>>8) != used.G || uint8(b>>8) != used.B {
+		t.Error(
+			"\n<<<input>>>\n", grid.Cells[0],
+			"\n<<<actual_output>>>\n", img.At(0, 0),
+			"\n<<<expected_output>>>\n", used,
+		)
+	}
+}
@@ -0,0 +1,68 @@
+package heatmap
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// colors of a free and a fully used cell
+var (
+	FreeColor = color.RGBA{0xe8, 0xf5, 0xe9, 0xff}
+	UsedColor = color.RGBA{0xb7, 0x1c, 0x1c, 0xff}
+)
+
+// Color returns the color of a cell with the utilization, which blends FreeColor into UsedColor
+func Color(utilization float64) color.RGBA {
+	utilization = max(0, min(1, utilization))
+	blend := func(free, used uint8) uint8 {
+		return uint8(float64(free) + (float64(used)-float64(free))*utilization + 0.5)
+	}
+	return color.RGBA{
+		blend(FreeColor.R, UsedColor.R),
+		blend(FreeColor.G, UsedColor.G),
+		blend(FreeColor.B, UsedColor.B),
+		0xff,
+	}
+}
+
+// Image returns the grid as an image with each cell drawn as a square with sides of
+// cellSize pixels, which is at least one
+func (g *Grid) Image(cellSize int) *image.RGBA {
+	cellSize = max(cellSize, 1)
+	img := image.NewRGBA(image.Rect(0, 0, g.Width*cellSize, g.Height*cellSize))
+	for _, cell := range g.Cells {
+		c := Color(cell.Utilization)
+		for y := cell.Y * cellSize; y < (cell.Y+1)*cellSize; y++ {
+			for x := cell.X * cellSize; x < (cell.X+1)*cellSize; x++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG encodes the image of the grid as a PNG, see Image
+func (g *Grid) WritePNG(w io.Writer, cellSize int) error {
+	return png.Encode(w, g.Image(cellSize))
+}
+
+// WriteSVG writes the grid as an SVG with a rect for each cell whose title holds the
+// network and utilization of the cell
+func (g *Grid) WriteSVG(w io.Writer, cellSize int) error {
+	cellSize = max(cellSize, 1)
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		g.Width*cellSize, g.Height*cellSize, g.Width*cellSize, g.Height*cellSize)
+	fmt.Fprintf(buffered, "<title>%v %.2f%% used</title>\n", g.Aggregate, g.Utilization()*100)
+	for _, cell := range g.Cells {
+		c := Color(cell.Utilization)
+		fmt.Fprintf(buffered, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"#%02x%02x%02x\"><title>%v %.2f%% used</title></rect>\n",
+			cell.X*cellSize, cell.Y*cellSize, cellSize, cellSize, c.R, c.G, c.B, cell.Network, cell.Utilization*100)
+	}
+	buffered.WriteString("</svg>\n")
+	return buffered.Flush()
+}
@@ -0,0 +1,115 @@
+package subnetmath
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"net"
+)
+
+const interfaceIdentifierLen = 8
+
+func isIPv6Network(network *net.IPNet) bool {
+	if network != nil && network.IP.To4() == nil && len(network.IP) == net.IPv6len {
+		_, bits := network.Mask.Size()
+		return bits == 128
+	}
+	return false
+}
+
+// EUI64Address returns the address formed by appending the modified EUI-64 interface
+// identifier of the MAC to the prefix. Both EUI-48 and EUI-64 MAC addresses are accepted.
+// Nil is returned if the prefix isn't an IPv6 network of /64 or shorter.
+func EUI64Address(prefix *net.IPNet, mac net.HardwareAddr) net.IP {
+	if !isIPv6Network(prefix) {
+		return nil
+	}
+	if ones, _ := prefix.Mask.Size(); ones > 64 {
+		return nil
+	}
+	identifier := make([]byte, interfaceIdentifierLen)
+	switch len(mac) {
+	case 6:
+		copy(identifier[:3], mac[:3])
+		identifier[3], identifier[4] = 0xff, 0xfe
+		copy(identifier[5:], mac[3:])
+	case 8:
+		copy(identifier, mac)
+	default:
+		return nil
+	}
+	identifier[0] ^= 0x02
+	return withInterfaceIdentifier(prefix, identifier)
+}
+
+// InterfaceIdentifier returns a copy of the lower 64 bits of an IPv6 address
+// or nil if given an IPv4 address
+func InterfaceIdentifier(address net.IP) []byte {
+	if address.To4() == nil && len(address) == net.IPv6len {
+		identifier := make([]byte, interfaceIdentifierLen)
+		copy(identifier, address[net.IPv6len-interfaceIdentifierLen:])
+		return identifier
+	}
+	return nil
+}
+
+// SolicitedNodeMulticast returns the ff02::1:ff00:0/104 solicited-node multicast
+// address of an IPv6 address or nil if given an IPv4 address
+func SolicitedNodeMulticast(address net.IP) net.IP {
+	if address.To4() == nil && len(address) == net.IPv6len {
+		multicast := net.ParseIP("ff02::1:ff00:0")
+		copy(multicast[13:], address[13:])
+		return multicast
+	}
+	return nil
+}
+
+// NibbleAlignedNetwork returns the smallest network on a nibble boundary that contains
+// the supplied IPv6 network. This is the unit that ip6.arpa delegation works with.
+func NibbleAlignedNetwork(network *net.IPNet) *net.IPNet {
+	if !isIPv6Network(network) {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	mask := net.CIDRMask(ones-ones%4, bits)
+	return &net.IPNet{IP: network.IP.Mask(mask), Mask: mask}
+}
+
+// StablePrivacyAddress returns an RFC 7217 semantically opaque address for the prefix.
+// The interface identifier is the lower 64 bits of SHA-256 over the prefix, interface name,
+// network ID, DAD counter and secret key. The prefix and DAD counter have fixed widths and
+// the other inputs are preceded by their length so that different inputs never hash the
+// same bytes. Callers should increment the DAD counter and try again if duplicate address
+// detection fails.
+func StablePrivacyAddress(prefix *net.IPNet, iface string, networkID []byte,
+	dadCounter uint8, secretKey []byte) net.IP {
+	if !isIPv6Network(prefix) {
+		return nil
+	}
+	if ones, _ := prefix.Mask.Size(); ones > 64 {
+		return nil
+	}
+	h := sha256.New()
+	h.Write(prefix.IP.Mask(prefix.Mask)[:net.IPv6len-interfaceIdentifierLen])
+	writeLengthPrefixed(h, []byte(iface))
+	writeLengthPrefixed(h, networkID)
+	h.Write([]byte{dadCounter})
+	writeLengthPrefixed(h, secretKey)
+	sum := h.Sum(nil)
+	return withInterfaceIdentifier(prefix, sum[len(sum)-interfaceIdentifierLen:])
+}
+
+// writeLengthPrefixed writes the length of b as 4 bytes followed by b
+func writeLengthPrefixed(h hash.Hash, b []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(b)))
+	h.Write(length[:])
+	h.Write(b)
+}
+
+func withInterfaceIdentifier(prefix *net.IPNet, identifier []byte) net.IP {
+	address := make(net.IP, net.IPv6len)
+	copy(address, prefix.IP.Mask(prefix.Mask))
+	copy(address[net.IPv6len-interfaceIdentifierLen:], identifier)
+	return address
+}
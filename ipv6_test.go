@@ -0,0 +1,86 @@
+package subnetmath
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestEUI64Address(t *testing.T) {
+	prefix := ParseNetworkCIDR("2001:db8:1:2::/64")
+	mac, _ := net.ParseMAC("00:25:96:12:34:56")
+	actualOutput := EUI64Address(prefix, mac)
+	expectedOutput := net.ParseIP("2001:db8:1:2:225:96ff:fe12:3456")
+	if !actualOutput.Equal(expectedOutput) {
+		t.Error("\n",
+			"<<<input>>>\n", prefix, mac,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+	if EUI64Address(ParseNetworkCIDR("2001:db8::/96"), mac) != nil {
+		t.Error("\n", "prefixes longer than /64 should be rejected")
+	}
+}
+
+func TestInterfaceIdentifier(t *testing.T) {
+	input := net.ParseIP("2001:db8::225:96ff:fe12:3456")
+	actualOutput := InterfaceIdentifier(input)
+	expectedOutput := []byte{0x02, 0x25, 0x96, 0xff, 0xfe, 0x12, 0x34, 0x56}
+	if !bytes.Equal(actualOutput, expectedOutput) {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+}
+
+func TestSolicitedNodeMulticast(t *testing.T) {
+	input := net.ParseIP("2001:db8::225:96ff:fe12:3456")
+	actualOutput := SolicitedNodeMulticast(input)
+	expectedOutput := net.ParseIP("ff02::1:ff12:3456")
+	if !actualOutput.Equal(expectedOutput) {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+}
+
+func TestNibbleAlignedNetwork(t *testing.T) {
+	input := ParseNetworkCIDR("2001:db8:ab80::/42")
+	actualOutput := NibbleAlignedNetwork(input)
+	expectedOutput := ParseNetworkCIDR("2001:db8:ab00::/40")
+	if !NetworksAreIdentical(actualOutput, expectedOutput) {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+}
+
+func TestStablePrivacyAddress(t *testing.T) {
+	prefix := ParseNetworkCIDR("2001:db8:1:2::/64")
+	secret := []byte("secret")
+	first := StablePrivacyAddress(prefix, "eth0", nil, 0, secret)
+	if !prefix.Contains(first) {
+		t.Error("\n", first, "is not within", prefix)
+	}
+	if !first.Equal(StablePrivacyAddress(prefix, "eth0", nil, 0, secret)) {
+		t.Error("\n", "addresses should be stable for the same inputs")
+	}
+	if first.Equal(StablePrivacyAddress(prefix, "eth0", nil, 1, secret)) {
+		t.Error("\n", "addresses should change with the DAD counter")
+	}
+	if first.Equal(StablePrivacyAddress(ParseNetworkCIDR("2001:db8:1:3::/64"), "eth0", nil, 0, secret)) {
+		t.Error("\n", "addresses should change with the prefix")
+	}
+	// without delimiters both would hash "eth01" followed by the same bytes
+	if StablePrivacyAddress(prefix, "eth0", []byte("1"), 0, secret).Equal(
+		StablePrivacyAddress(prefix, "eth01", nil, 0, secret)) {
+		t.Error("\n", "addresses should change when bytes move between the interface and network ID")
+	}
+}
@@ -0,0 +1,33 @@
+package subnetmath
+
+import (
+	"iter"
+	"net"
+)
+
+// InbetweenSubnets returns an iterator over the subnets of FindInbetweenSubnets.
+// Subnets are computed lazily as the iteration progresses and every subnet is owned
+// by the caller. Breaking out of the loop stops the computation.
+func InbetweenSubnets(start, stop net.IP) iter.Seq[*net.IPNet] {
+	return func(yield func(*net.IPNet) bool) {
+		walkInbetweenSubnets(start, stop, func(addr uint128, ones int, family Family) bool {
+			subnet := &net.IPNet{}
+			setNetwork(subnet, addr, ones, family)
+			return yield(subnet)
+		})
+	}
+}
+
+// UnusedSubnets returns an iterator over the subnets of FindUnusedSubnets.
+// Subnets are computed lazily as the iteration progresses and every subnet is owned
+// by the caller. Breaking out of the loop stops the computation. Note that the sibling
+// subnets are read each time an iteration starts.
+func UnusedSubnets(aggregate *net.IPNet, subnets ...*net.IPNet) iter.Seq[*net.IPNet] {
+	return func(yield func(*net.IPNet) bool) {
+		walkUnusedSubnets(aggregate, subnets, func(addr uint128, ones int, family Family) bool {
+			subnet := &net.IPNet{}
+			setNetwork(subnet, addr, ones, family)
+			return yield(subnet)
+		})
+	}
+}
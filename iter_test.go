@@ -0,0 +1,75 @@
+package subnetmath
+
+import (
+	"net"
+	"testing"
+)
+
+func TestInbetweenSubnets(t *testing.T) {
+	start, stop := net.ParseIP("2001:400::"), net.ParseIP("2001:440:ffff:ffff:7fff:ffff:ffff:ffff")
+	var output []*net.IPNet
+	for subnet := range InbetweenSubnets(start, stop) {
+		output = append(output, subnet)
+	}
+	expected := FindInbetweenSubnets(start, stop)
+	if !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error("\n",
+			"<<<input>>>\n", start, stop,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	output = output[:0]
+	for subnet := range InbetweenSubnets(start, stop) {
+		output = append(output, subnet)
+		if len(output) == 3 {
+			break
+		}
+	}
+	if !sliceOfSubnetsAreEqual(output, expected[:3]) {
+		t.Error("\n",
+			"<<<input>>>\n", start, stop,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", expected[:3],
+		)
+	}
+}
+
+func TestUnusedSubnets(t *testing.T) {
+	for _, input := range bufferedUnusedInputs {
+		aggregate := ParseNetworkCIDR(input.aggregate)
+		var subnets []*net.IPNet
+		for _, subnet := range input.subnets {
+			subnets = append(subnets, ParseNetworkCIDR(subnet))
+		}
+		var output []*net.IPNet
+		for subnet := range UnusedSubnets(aggregate, subnets...) {
+			output = append(output, subnet)
+		}
+		expected := FindUnusedSubnets(aggregate, subnets...)
+		if !sliceOfSubnetsAreEqual(output, expected) {
+			t.Error(
+				"\n<<<input>>>\n", "aggregate:", aggregate, "\n", subnets,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+	aggregate := ParseNetworkCIDR("2001:db8::/32")
+	sparse := []*net.IPNet{
+		ParseNetworkCIDR("2001:db8::1/128"),
+		ParseNetworkCIDR("2001:db8:ffff:ffff:ffff:ffff:ffff:fffe/128"),
+	}
+	count := 0
+	for subnet := range UnusedSubnets(aggregate, sparse...) {
+		if count == 0 && !NetworksAreIdentical(subnet, ParseNetworkCIDR("2001:db8::/128")) {
+			t.Error("\n", "unexpected first subnet", subnet)
+		}
+		if count++; count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Error("\n", "expected to stop after 5 subnets but got", count)
+	}
+}
@@ -0,0 +1,109 @@
+package subnetmath
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// ErrLimitExceeded is matched by errors.Is for every *LimitError
+var ErrLimitExceeded = errors.New("subnetmath: limit exceeded")
+
+// Limits bounds the work done by the Ctx variants of the subnet finders.
+// A zero value for either field means that it is unlimited.
+type Limits struct {
+	// MaxResults is the maximum number of subnets that may be returned
+	MaxResults int
+	// MaxIterations is the maximum number of steps that may be taken where each
+	// sibling subnet and each subnet produced counts as one step
+	MaxIterations int
+}
+
+// LimitError describes which of the Limits was exceeded
+type LimitError struct {
+	Limit string
+	Value int
+}
+
+func (e *LimitError) Error() string {
+	return "subnetmath: limit exceeded: " + e.Limit + " of " + strconv.Itoa(e.Value)
+}
+
+// Is reports whether the target is ErrLimitExceeded
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// limitedCollector appends subnets until the context is done or the limits are exceeded
+type limitedCollector struct {
+	ctx        context.Context
+	limits     Limits
+	iterations int
+	subnets    []*net.IPNet
+	err        error
+}
+
+func (c *limitedCollector) step(count int) bool {
+	c.iterations += count
+	if c.limits.MaxIterations > 0 && c.iterations > c.limits.MaxIterations {
+		c.err = &LimitError{"MaxIterations", c.limits.MaxIterations}
+		return false
+	}
+	if err := c.ctx.Err(); err != nil {
+		c.err = err
+		return false
+	}
+	return true
+}
+
+// done reports whether the context is done without counting a step
+func (c *limitedCollector) done() bool {
+	if err := c.ctx.Err(); err != nil {
+		c.err = err
+		return true
+	}
+	return false
+}
+
+func (c *limitedCollector) emit(addr uint128, ones int, family Family) bool {
+	if !c.step(1) {
+		return false
+	}
+	if c.limits.MaxResults > 0 && len(c.subnets) >= c.limits.MaxResults {
+		c.err = &LimitError{"MaxResults", c.limits.MaxResults}
+		return false
+	}
+	c.subnets = appendNetworkPointer(c.subnets, addr, ones, family)
+	return true
+}
+
+// FindInbetweenSubnetsCtx is FindInbetweenSubnetsStrict bounded by a context and limits.
+// If the context is done or a limit is exceeded the subnets found so far are returned
+// along with either the context error or a *LimitError.
+func FindInbetweenSubnetsCtx(ctx context.Context, limits Limits, start, stop net.IP) ([]*net.IPNet, error) {
+	if err := validateInbetween(start, stop); err != nil {
+		return nil, err
+	}
+	collector := &limitedCollector{ctx: ctx, limits: limits}
+	if collector.step(0) {
+		walkInbetweenSubnetsStrict(start, stop, collector.emit)
+	}
+	return collector.subnets, collector.err
+}
+
+// FindUnusedSubnetsCtx is FindUnusedSubnetsStrict bounded by a context and limits.
+// If the context is done or a limit is exceeded the subnets found so far are returned
+// along with either the context error or a *LimitError. The context is also checked
+// periodically while the sibling subnets are sorted.
+func FindUnusedSubnetsCtx(ctx context.Context, limits Limits, aggregate *net.IPNet,
+	subnets ...*net.IPNet) ([]*net.IPNet, error) {
+	if err := validateUnused(aggregate, subnets); err != nil {
+		return nil, err
+	}
+	collector := &limitedCollector{ctx: ctx, limits: limits}
+	if collector.step(len(subnets)) {
+		walkUnusedSubnetsUntil(aggregate, subnets, collector.done, collector.emit)
+	}
+	return collector.subnets, collector.err
+}
@@ -0,0 +1,96 @@
+package subnetmath
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestFindInbetweenSubnetsCtx(t *testing.T) {
+	start, stop := net.ParseIP("192.168.1.2"), net.ParseIP("192.168.2.2")
+	expected := FindInbetweenSubnets(start, stop)
+	output, err := FindInbetweenSubnetsCtx(context.Background(), Limits{}, start, stop)
+	if err != nil || !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error("\n",
+			"<<<input>>>\n", start, stop,
+			"\n<<<actual_output>>>\n", output, err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	output, err = FindInbetweenSubnetsCtx(context.Background(), Limits{MaxResults: len(expected)}, start, stop)
+	if err != nil || len(output) != len(expected) {
+		t.Error("\n", "an exact MaxResults should not be exceeded", output, err)
+	}
+	output, err = FindInbetweenSubnetsCtx(context.Background(), Limits{MaxResults: 4}, start, stop)
+	var limitErr *LimitError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Limit != "MaxResults" ||
+		!sliceOfSubnetsAreEqual(output, expected[:4]) {
+		t.Error("\n", "expected the first 4 subnets and a MaxResults error but got", output, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	output, err = FindInbetweenSubnetsCtx(ctx, Limits{}, start, stop)
+	if err != context.Canceled || len(output) != 0 {
+		t.Error("\n", "expected no subnets and context.Canceled but got", output, err)
+	}
+}
+
+func TestFindUnusedSubnetsCtx(t *testing.T) {
+	aggregate := ParseNetworkCIDR("192.168.0.0/22")
+	subnets := []*net.IPNet{
+		ParseNetworkCIDR("192.168.1.0/24"),
+		ParseNetworkCIDR("192.168.2.32/30"),
+	}
+	expected := FindUnusedSubnets(aggregate, subnets...)
+	output, err := FindUnusedSubnetsCtx(context.Background(), Limits{}, aggregate, subnets...)
+	if err != nil || !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error(
+			"\n<<<input>>>\n", "aggregate:", aggregate, "\n", subnets,
+			"\n<<<actual_output>>>\n", output, err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	output, err = FindUnusedSubnetsCtx(context.Background(), Limits{MaxIterations: 5}, aggregate, subnets...)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxIterations" ||
+		!sliceOfSubnetsAreEqual(output, expected[:3]) {
+		t.Error("\n", "expected the first 3 subnets and a MaxIterations error but got", output, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = FindUnusedSubnetsCtx(ctx, Limits{}, aggregate, subnets...); err != context.Canceled {
+		t.Error("\n", "expected context.Canceled but got", err)
+	}
+}
+
+// cancelAfterContext is canceled once Err has been called the given number of times
+type cancelAfterContext struct {
+	context.Context
+	calls, cancelAfter int
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.calls++; c.calls >= c.cancelAfter {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestFindUnusedSubnetsCtxCancelWhileSorting(t *testing.T) {
+	aggregate := ParseNetworkCIDR("10.0.0.0/8")
+	// the siblings cover the whole aggregate so no subnet is ever produced
+	subnets := make([]*net.IPNet, 5000)
+	for i := range subnets {
+		subnets[i] = ParseNetworkCIDR("10.0.0.0/8")
+	}
+	ctx := &cancelAfterContext{Context: context.Background(), cancelAfter: 2}
+	output, err := FindUnusedSubnetsCtx(ctx, Limits{}, aggregate, subnets...)
+	if err != context.Canceled || output != nil || ctx.calls != 2 {
+		t.Error(
+			"\n<<<input>>>\n", "aggregate:", aggregate, "\n", len(subnets), "siblings",
+			"\n<<<actual_output>>>\n", output, err, ctx.calls,
+			"\n<<<expected_output>>>\n", nil, context.Canceled, 2,
+		)
+	}
+}
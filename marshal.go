@@ -0,0 +1,128 @@
+package subnetmath
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// Network wraps a *net.IPNet so that it encodes as a CIDR string rather than as the bytes
+// of its IP and Mask. It implements encoding.TextMarshaler and encoding.TextUnmarshaler,
+// which YAML and most other encoders use, along with json.Marshaler, json.Unmarshaler,
+// sql.Scanner and driver.Valuer. A Network holding a nil *net.IPNet encodes as JSON null,
+// SQL NULL or empty text.
+type Network struct {
+	*net.IPNet
+}
+
+// WrapNetworks returns the networks as a slice of Network, such as the results of FindUnusedSubnets
+func WrapNetworks(networks []*net.IPNet) []Network {
+	if networks == nil {
+		return nil
+	}
+	wrapped := make([]Network, len(networks))
+	for i, network := range networks {
+		wrapped[i] = Network{network}
+	}
+	return wrapped
+}
+
+// UnwrapNetworks returns the *net.IPNet held by each Network
+func UnwrapNetworks(networks []Network) []*net.IPNet {
+	if networks == nil {
+		return nil
+	}
+	unwrapped := make([]*net.IPNet, len(networks))
+	for i, network := range networks {
+		unwrapped[i] = network.IPNet
+	}
+	return unwrapped
+}
+
+// String returns the CIDR notation of the network or an empty string if it is nil
+func (n Network) String() string {
+	if n.IPNet == nil {
+		return ""
+	}
+	return n.IPNet.String()
+}
+
+// MarshalText returns the CIDR notation of the network. A *ValidationError is returned if the
+// network can't be parsed back by UnmarshalText, see ValidateNetwork.
+func (n Network) MarshalText() ([]byte, error) {
+	if n.IPNet == nil {
+		return []byte{}, nil
+	}
+	if err := ValidateNetwork(n.IPNet); err != nil {
+		return nil, err
+	}
+	return []byte(n.IPNet.String()), nil
+}
+
+// UnmarshalText parses the CIDR notation with ParseNetworkCIDRStrict. Empty text results in
+// a nil network.
+func (n *Network) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		n.IPNet = nil
+		return nil
+	}
+	network, err := ParseNetworkCIDRStrict(string(text))
+	if err != nil {
+		return err
+	}
+	n.IPNet = network
+	return nil
+}
+
+// MarshalJSON returns the CIDR notation of the network as a JSON string or null if it is nil
+func (n Network) MarshalJSON() ([]byte, error) {
+	if n.IPNet == nil {
+		return []byte("null"), nil
+	}
+	text, err := n.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON parses a JSON string holding CIDR notation. Null results in a nil network.
+func (n *Network) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		n.IPNet = nil
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return n.UnmarshalText([]byte(text))
+}
+
+// Scan implements sql.Scanner for columns holding CIDR notation as text. NULL results in
+// a nil network.
+func (n *Network) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		n.IPNet = nil
+		return nil
+	case string:
+		return n.UnmarshalText([]byte(src))
+	case []byte:
+		return n.UnmarshalText(src)
+	}
+	return fmt.Errorf("subnetmath: cannot scan %T into Network", src)
+}
+
+// Value implements driver.Valuer and returns the CIDR notation of the network or nil
+func (n Network) Value() (driver.Value, error) {
+	if n.IPNet == nil {
+		return nil, nil
+	}
+	text, err := n.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
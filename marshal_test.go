@@ -0,0 +1,85 @@
+package subnetmath
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestNetworkJSON(t *testing.T) {
+	unused := FindUnusedSubnets(ParseNetworkCIDR("192.168.0.0/22"), ParseNetworkCIDR("192.168.1.0/24"))
+	input := struct {
+		Unused []Network `json:"unused"`
+		Parent Network   `json:"parent"`
+		Empty  Network   `json:"empty"`
+	}{WrapNetworks(unused), Network{ParseNetworkCIDR("2001:db8::/32")}, Network{}}
+	output, err := json.Marshal(input)
+	expected := `{"unused":["192.168.0.0/24","192.168.2.0/23"],"parent":"2001:db8::/32","empty":null}`
+	if err != nil || string(output) != expected {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", string(output), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	roundTrip := input
+	roundTrip.Unused, roundTrip.Parent = nil, Network{}
+	err = json.Unmarshal(output, &roundTrip)
+	if err != nil || !sliceOfSubnetsAreEqual(UnwrapNetworks(roundTrip.Unused), unused) ||
+		!NetworksAreIdentical(roundTrip.Parent.IPNet, input.Parent.IPNet) || roundTrip.Empty.IPNet != nil {
+		t.Error(
+			"\n<<<input>>>\n", string(output),
+			"\n<<<actual_output>>>\n", roundTrip, err,
+			"\n<<<expected_output>>>\n", input,
+		)
+	}
+}
+
+func TestNetworkTextErrors(t *testing.T) {
+	var network Network
+	for input, expected := range map[string]error{
+		"192.168.0.0/33": ErrInvalidCIDR,
+		"192.168.0.1/24": ErrHostBitsSet,
+		"not a network":  ErrInvalidCIDR,
+	} {
+		if err := network.UnmarshalText([]byte(input)); !errors.Is(err, expected) {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+	nonContiguous := Network{&net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPMask{255, 0, 255, 0}}}
+	if _, err := nonContiguous.MarshalText(); !errors.Is(err, ErrNonContiguousMask) {
+		t.Error(
+			"\n<<<input>>>\n", nonContiguous.IPNet,
+			"\n<<<actual_output>>>\n", err,
+			"\n<<<expected_output>>>\n", ErrNonContiguousMask,
+		)
+	}
+}
+
+func TestNetworkSQL(t *testing.T) {
+	for _, input := range []interface{}{"10.0.0.0/8", []byte("10.0.0.0/8"), nil} {
+		var network Network
+		err := network.Scan(input)
+		value, valueErr := network.Value()
+		if err != nil || valueErr != nil || (input == nil) != (value == nil) || value != nil && value != "10.0.0.0/8" {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", value, err, valueErr,
+				"\n<<<expected_output>>>\n", "10.0.0.0/8",
+			)
+		}
+	}
+	var network Network
+	if err := network.Scan(42); err == nil {
+		t.Error(
+			"\n<<<input>>>\n", 42,
+			"\n<<<actual_output>>>\n", network,
+			"\n<<<expected_output>>>\n", "an error",
+		)
+	}
+}
@@ -0,0 +1,180 @@
+package netlist
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+)
+
+// CSVOptions configures the columns of a CSV list. Columns are referred to by their name
+// when the list has a header and otherwise by their index starting at zero.
+type CSVOptions struct {
+	// Comma is the field delimiter and defaults to ','
+	Comma rune
+	// Header reports whether the first record names the columns
+	Header bool
+	// Network is the column holding CIDR notation, a single address or a range of addresses.
+	// It defaults to "network" with a header and "0" without one.
+	Network string
+	// Start and Stop are the columns of an inclusive range of addresses that is expanded
+	// into one entry per subnet. They are used instead of Network when both are set.
+	Start, Stop string
+}
+
+func (o CSVOptions) networkColumn() string {
+	switch {
+	case o.Network != "":
+		return o.Network
+	case o.Header:
+		return "network"
+	}
+	return "0"
+}
+
+// CSVReader reads a list with one network or range of addresses per record. The other
+// columns of each record are returned as the Fields of its entries.
+type CSVReader struct {
+	reader  *csv.Reader
+	options CSVOptions
+	columns []string
+	pending []Entry
+}
+
+// NewCSVReader returns a CSVReader that reads from r
+func NewCSVReader(r io.Reader, options CSVOptions) *CSVReader {
+	reader := csv.NewReader(r)
+	if options.Comma != 0 {
+		reader.Comma = options.Comma
+	}
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &CSVReader{reader: reader, options: options}
+}
+
+// Read returns the next entry or io.EOF once the list is exhausted
+func (r *CSVReader) Read() (Entry, error) {
+	for len(r.pending) == 0 {
+		record, err := r.reader.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return Entry{}, &ParseError{parseErr.Line, parseErr.Err}
+			}
+			return Entry{}, err
+		}
+		line, _ := r.reader.FieldPos(0)
+		if r.columns == nil {
+			if r.options.Header {
+				r.columns = append([]string{}, record...)
+				continue
+			}
+			r.columns = []string{}
+		}
+		if err := r.parseRecord(record, line); err != nil {
+			return Entry{}, &ParseError{line, err}
+		}
+	}
+	entry := r.pending[0]
+	r.pending = r.pending[1:]
+	return entry, nil
+}
+
+func (r *CSVReader) parseRecord(record []string, line int) error {
+	fields := make(map[string]string, len(record))
+	for i, value := range record {
+		fields[r.columnName(i)] = value
+	}
+	var consumed []string
+	var text string
+	if r.options.Start != "" && r.options.Stop != "" {
+		consumed = []string{r.options.Start, r.options.Stop}
+		text = fields[r.options.Start] + "-" + fields[r.options.Stop]
+	} else {
+		consumed = []string{r.options.networkColumn()}
+		text = fields[consumed[0]]
+	}
+	for _, column := range consumed {
+		if _, found := fields[column]; !found {
+			return fmt.Errorf("missing column %q", column)
+		}
+		delete(fields, column)
+	}
+	networks, err := parseNetworks(text)
+	if err != nil {
+		return err
+	}
+	for i, network := range networks {
+		if i > 0 {
+			fields = maps.Clone(fields)
+		}
+		r.pending = append(r.pending, Entry{Network: network, Line: line, Fields: fields})
+	}
+	return nil
+}
+
+func (r *CSVReader) columnName(i int) string {
+	if i < len(r.columns) {
+		return r.columns[i]
+	}
+	return strconv.Itoa(i)
+}
+
+// CSVWriter writes a list with the network of each entry followed by the given fields
+type CSVWriter struct {
+	writer  *csv.Writer
+	options CSVOptions
+	fields  []string
+	record  []string
+	started bool
+}
+
+// NewCSVWriter returns a CSVWriter that writes to w. The network is written to the first
+// column, named by options.Network when a header is written, and the named fields of each
+// entry to the following columns. Ranges of addresses aren't written so options.Start and
+// options.Stop are ignored.
+func NewCSVWriter(w io.Writer, options CSVOptions, fields ...string) *CSVWriter {
+	writer := csv.NewWriter(w)
+	if options.Comma != 0 {
+		writer.Comma = options.Comma
+	}
+	return &CSVWriter{writer: writer, options: options, fields: fields, record: make([]string, len(fields)+1)}
+}
+
+// Write writes the network and fields of the entry
+func (w *CSVWriter) Write(entry Entry) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	text, err := formatNetwork(entry.Network)
+	if err != nil {
+		return err
+	}
+	w.record[0] = text
+	for i, field := range w.fields {
+		w.record[i+1] = entry.Fields[field]
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *CSVWriter) writeHeader() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if !w.options.Header {
+		return nil
+	}
+	return w.writer.Write(append([]string{w.options.networkColumn()}, w.fields...))
+}
+
+// Close writes the header if nothing else was written and flushes the buffered output
+func (w *CSVWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
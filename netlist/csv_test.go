@@ -0,0 +1,68 @@
+package netlist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/demskie/subnetmath"
+)
+
+func TestCSVReader(t *testing.T) {
+	input := "site,cidr,vlan\nnyc,10.1.0.0/16,10\nlon,\"10.2.0.0 - 10.2.1.255\",20\n"
+	output, err := ReadAll(NewCSVReader(strings.NewReader(input), CSVOptions{Header: true, Network: "cidr"}))
+	expected := []string{"10.1.0.0/16 line 2 nyc 10", "10.2.0.0/23 line 3 lon 20"}
+	var actual []string
+	for _, entry := range output {
+		actual = append(actual, entryStrings([]Entry{entry})[0]+" "+entry.Fields["site"]+" "+entry.Fields["vlan"])
+	}
+	if err != nil || strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actual, err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestCSVReaderRangeColumns(t *testing.T) {
+	input := "a;10.0.0.0;10.0.0.2\nb;10.0.1.0;10.0.0.0\n"
+	reader := NewCSVReader(strings.NewReader(input), CSVOptions{Comma: ';', Start: "1", Stop: "2"})
+	output, err := ReadNetworks(reader)
+	expected := []string{"10.0.0.0/31", "10.0.0.2/32"}
+	var actual []string
+	for _, network := range output {
+		actual = append(actual, network.String())
+	}
+	var parseErr *ParseError
+	if strings.Join(actual, " ") != strings.Join(expected, " ") || !errors.Is(err, subnetmath.ErrReversedRange) ||
+		!errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actual, err,
+			"\n<<<expected_output>>>\n", expected, "line 2:", subnetmath.ErrReversedRange,
+		)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var output strings.Builder
+	writer := NewCSVWriter(&output, CSVOptions{Header: true}, "site")
+	writer.Write(Entry{Network: subnetmath.ParseNetworkCIDR("10.1.0.0/16"), Fields: map[string]string{"site": "nyc, ny"}})
+	writer.Write(Entry{Network: subnetmath.ParseNetworkCIDR("2001:db8::/32")})
+	err := writer.Close()
+	expected := "network,site\n10.1.0.0/16,\"nyc, ny\"\n2001:db8::/32,\n"
+	if err != nil || output.String() != expected {
+		t.Error(
+			"\n<<<actual_output>>>\n", output.String(), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	roundTrip, err := ReadAll(NewCSVReader(strings.NewReader(output.String()), CSVOptions{Header: true}))
+	if err != nil || len(roundTrip) != 2 || roundTrip[0].Fields["site"] != "nyc, ny" {
+		t.Error(
+			"\n<<<input>>>\n", output.String(),
+			"\n<<<actual_output>>>\n", roundTrip, err,
+		)
+	}
+}
@@ -0,0 +1,221 @@
+package netlist
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// JSONReader reads a JSON array whose elements are either strings holding CIDR notation,
+// a single address or a range of addresses, or objects holding such a string in their
+// "network" member. The "comment" member of an object becomes the Comment of its entries
+// and its other members become Fields, strings unquoted and other values as JSON text.
+type JSONReader struct {
+	decoder *json.Decoder
+	lines   *lineCounter
+	started bool
+	done    bool
+	pending []Entry
+}
+
+// NewJSONReader returns a JSONReader that reads from r
+func NewJSONReader(r io.Reader) *JSONReader {
+	lines := &lineCounter{r: r}
+	return &JSONReader{decoder: json.NewDecoder(lines), lines: lines}
+}
+
+// Read returns the next entry or io.EOF once the array is exhausted
+func (r *JSONReader) Read() (Entry, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return Entry{}, io.EOF
+		}
+		if !r.started {
+			token, err := r.decoder.Token()
+			if err != nil {
+				return Entry{}, r.decodeError(err)
+			}
+			if token != json.Delim('[') {
+				return Entry{}, &ParseError{r.lines.lineAt(r.decoder.InputOffset()), errors.New("expected an array")}
+			}
+			r.started = true
+		}
+		if !r.decoder.More() {
+			if _, err := r.decoder.Token(); err != nil {
+				return Entry{}, r.decodeError(err)
+			}
+			r.done = true
+			continue
+		}
+		var element json.RawMessage
+		if err := r.decoder.Decode(&element); err != nil {
+			return Entry{}, r.decodeError(err)
+		}
+		line := r.lines.lineAt(r.decoder.InputOffset() - int64(len(element)))
+		if err := r.parseElement(element, line); err != nil {
+			return Entry{}, &ParseError{line, err}
+		}
+	}
+	entry := r.pending[0]
+	r.pending = r.pending[1:]
+	return entry, nil
+}
+
+func (r *JSONReader) parseElement(element json.RawMessage, line int) error {
+	entry := Entry{Line: line}
+	var text string
+	switch element[0] {
+	case '"':
+		if err := json.Unmarshal(element, &text); err != nil {
+			return err
+		}
+	case '{':
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(element, &members); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(members["network"], &text); err != nil || members["network"] == nil {
+			return errors.New(`expected a string "network" member`)
+		}
+		if comment, found := members["comment"]; found {
+			if err := json.Unmarshal(comment, &entry.Comment); err != nil {
+				return errors.New(`expected a string "comment" member`)
+			}
+		}
+		delete(members, "network")
+		delete(members, "comment")
+		entry.Fields = make(map[string]string, len(members))
+		for name, value := range members {
+			var unquoted string
+			if json.Unmarshal(value, &unquoted) == nil {
+				entry.Fields[name] = unquoted
+			} else {
+				entry.Fields[name] = string(value)
+			}
+		}
+	default:
+		return fmt.Errorf("expected a string or object but found %s", element)
+	}
+	networks, err := parseNetworks(strings.TrimSpace(text))
+	if err != nil {
+		return err
+	}
+	for i, network := range networks {
+		if i > 0 {
+			entry.Fields = maps.Clone(entry.Fields)
+		}
+		entry.Network = network
+		r.pending = append(r.pending, entry)
+	}
+	return nil
+}
+
+// decodeError returns the error of the decoder with the line it occurred on
+func (r *JSONReader) decodeError(err error) error {
+	offset := r.decoder.InputOffset()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return &ParseError{r.lines.lineAt(offset), err}
+}
+
+// lineCounter records the offsets of the newlines read through it so that the offsets
+// reported by a json.Decoder can be converted into line numbers
+type lineCounter struct {
+	r        io.Reader
+	read     int64
+	newlines []int64
+	line     int
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// lineAt returns the line of the offset which must not come before previous offsets
+func (c *lineCounter) lineAt(offset int64) int {
+	for len(c.newlines) > 0 && c.newlines[0] < offset {
+		c.newlines = c.newlines[1:]
+		c.line++
+	}
+	return c.line + 1
+}
+
+// JSONWriter writes a JSON array with one element per line. Entries without a comment
+// or fields are written as strings and the others as objects.
+type JSONWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+// NewJSONWriter returns a JSONWriter that writes to w
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes the entry as the next element of the array
+func (w *JSONWriter) Write(entry Entry) error {
+	text, err := formatNetwork(entry.Network)
+	if err != nil {
+		return err
+	}
+	element, _ := json.Marshal(text)
+	if entry.Comment != "" || len(entry.Fields) > 0 {
+		element = append([]byte(`{"network":`), element...)
+		if entry.Comment != "" {
+			element = appendMember(element, "comment", entry.Comment)
+		}
+		names := make([]string, 0, len(entry.Fields))
+		for name := range entry.Fields {
+			if name != "network" && name != "comment" {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			element = appendMember(element, name, entry.Fields[name])
+		}
+		element = append(element, '}')
+	}
+	separator := ",\n  "
+	if w.count == 0 {
+		separator = "[\n  "
+	}
+	w.count++
+	w.w.WriteString(separator)
+	_, err = w.w.Write(element)
+	return err
+}
+
+func appendMember(element []byte, name, value string) []byte {
+	quotedName, _ := json.Marshal(name)
+	quotedValue, _ := json.Marshal(value)
+	element = append(append(element, ','), quotedName...)
+	return append(append(element, ':'), quotedValue...)
+}
+
+// Close completes the array and flushes the buffered output
+func (w *JSONWriter) Close() error {
+	if w.count == 0 {
+		w.w.WriteString("[]\n")
+	} else {
+		w.w.WriteString("\n]\n")
+	}
+	return w.w.Flush()
+}
@@ -0,0 +1,92 @@
+package netlist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/demskie/subnetmath"
+)
+
+func TestJSONReader(t *testing.T) {
+	input := `[
+  "192.168.1.0/24",
+  {"network": "10.0.0.6-10.0.0.7", "comment": "lab", "vlan": 20, "site": "nyc"},
+  "2001:db8::1"
+]`
+	output, err := ReadAll(NewJSONReader(strings.NewReader(input)))
+	expected := []string{"192.168.1.0/24 line 2", "10.0.0.6/31 line 3 lab", "2001:db8::1/128 line 4"}
+	if err != nil || strings.Join(entryStrings(output), "\n") != strings.Join(expected, "\n") ||
+		output[1].Fields["vlan"] != "20" || output[1].Fields["site"] != "nyc" {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", entryStrings(output), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestJSONReaderRangeFields(t *testing.T) {
+	input := `[{"network": "10.0.0.6-10.0.0.9", "vlan": "20"}]`
+	output, err := ReadAll(NewJSONReader(strings.NewReader(input)))
+	if err != nil || len(output) != 2 {
+		t.Fatal(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", entryStrings(output), err,
+			"\n<<<expected_output>>>\n", "two entries",
+		)
+	}
+	output[0].Fields["vlan"] = "30"
+	if output[1].Fields["vlan"] != "20" {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", output[1].Fields,
+			"\n<<<expected_output>>>\n", map[string]string{"vlan": "20"},
+		)
+	}
+}
+
+func TestJSONReaderErrors(t *testing.T) {
+	for input, expected := range map[string]error{
+		"[\n\"10.0.0.0/8\",\n\"10.0.0.1/8\"]":       subnetmath.ErrHostBitsSet,
+		"[\n\"10.0.0.0/8\",\n{\"network\": 42}]":    nil,
+		"[\n\"10.0.0.0/8\",\n\"10.0.0.0/8\" \"x\"]": nil,
+		"[\n\"10.0.0.0/8\",\n\"10.0.0.0/8\"":        nil,
+	} {
+		_, err := ReadNetworks(NewJSONReader(strings.NewReader(input)))
+		var parseErr *ParseError
+		if err == nil || expected != nil && !errors.Is(err, expected) || !errors.As(err, &parseErr) || parseErr.Line != 3 {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", "line 3:", expected,
+			)
+		}
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var output strings.Builder
+	writer := NewJSONWriter(&output)
+	writer.Write(Entry{Network: subnetmath.ParseNetworkCIDR("10.0.0.0/8")})
+	writer.Write(Entry{
+		Network: subnetmath.ParseNetworkCIDR("2001:db8::/32"),
+		Comment: "documentation",
+		Fields:  map[string]string{"site": "nyc", "network": "ignored"},
+	})
+	err := writer.Close()
+	expected := "[\n  \"10.0.0.0/8\",\n  {\"network\":\"2001:db8::/32\",\"comment\":\"documentation\",\"site\":\"nyc\"}\n]\n"
+	if err != nil || output.String() != expected {
+		t.Error(
+			"\n<<<actual_output>>>\n", output.String(), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	output.Reset()
+	if err := NewJSONWriter(&output).Close(); err != nil || output.String() != "[]\n" {
+		t.Error(
+			"\n<<<actual_output>>>\n", output.String(), err,
+			"\n<<<expected_output>>>\n", "[]",
+		)
+	}
+}
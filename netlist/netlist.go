@@ -0,0 +1,152 @@
+// Package netlist reads and writes lists of networks as plain text, CSV and JSON so that
+// they can be passed to the functions of subnetmath. Lists are read and written one entry
+// at a time so that large files can be streamed, and networks are parsed with
+// subnetmath.ParseNetworkCIDRStrict with errors reporting the offending line.
+package netlist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/demskie/subnetmath"
+)
+
+// Entry is a network read from or written to a list along with its metadata
+type Entry struct {
+	Network *net.IPNet
+	// Line is the line number that the entry was read from starting at one
+	Line int
+	// Comment holds the trailing comment of a text list or the comment of a JSON object
+	Comment string
+	// Fields holds the remaining columns of a CSV list or members of a JSON object
+	Fields map[string]string
+}
+
+// Reader returns the entries of a list one at a time and io.EOF once the list is exhausted
+type Reader interface {
+	Read() (Entry, error)
+}
+
+// Writer writes the entries of a list. Close must be called to complete the list and
+// flush buffered output but it does not close the underlying io.Writer.
+type Writer interface {
+	Write(entry Entry) error
+	Close() error
+}
+
+// ParseError reports the line of a list that could not be read
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("netlist: line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error so that errors.Is can match the errors of subnetmath
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ReadAll returns every entry of the list
+func ReadAll(r Reader) ([]Entry, error) {
+	var entries []Entry
+	for {
+		entry, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// ReadNetworks returns every network of the list, such as the sibling subnets of
+// subnetmath.FindUnusedSubnets
+func ReadNetworks(r Reader) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for {
+		entry, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return networks, nil
+		}
+		if err != nil {
+			return networks, err
+		}
+		networks = append(networks, entry.Network)
+	}
+}
+
+// WriteNetworks writes the networks without metadata and closes the Writer
+func WriteNetworks(w Writer, networks []*net.IPNet) error {
+	for _, network := range networks {
+		if err := w.Write(Entry{Network: network}); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// parseNetworks parses CIDR notation, a single address or an inclusive range of addresses
+// separated by a hyphen. Ranges are expanded with subnetmath.FindInbetweenSubnetsStrict.
+func parseNetworks(text string) ([]*net.IPNet, error) {
+	if start, stop, found := strings.Cut(text, "-"); found {
+		return parseRange(strings.TrimSpace(start), strings.TrimSpace(stop))
+	}
+	if strings.Contains(text, "/") {
+		network, err := subnetmath.ParseNetworkCIDRStrict(text)
+		if err != nil {
+			return nil, err
+		}
+		return []*net.IPNet{network}, nil
+	}
+	host, err := parseHost("address", text)
+	if err != nil {
+		return nil, err
+	}
+	return []*net.IPNet{host}, nil
+}
+
+func parseRange(startText, stopText string) ([]*net.IPNet, error) {
+	start := net.ParseIP(startText)
+	if start == nil {
+		return nil, invalidAddress("start", startText)
+	}
+	stop := net.ParseIP(stopText)
+	if stop == nil {
+		return nil, invalidAddress("stop", stopText)
+	}
+	return subnetmath.FindInbetweenSubnetsStrict(start, stop)
+}
+
+// parseHost returns the single address network of the address
+func parseHost(arg, text string) (*net.IPNet, error) {
+	address := net.ParseIP(text)
+	if address == nil {
+		return nil, invalidAddress(arg, text)
+	}
+	if address.To4() != nil {
+		return subnetmath.ParseNetworkCIDRStrict(address.String() + "/32")
+	}
+	return subnetmath.ParseNetworkCIDRStrict(address.String() + "/128")
+}
+
+func invalidAddress(arg, text string) error {
+	return &subnetmath.ValidationError{Arg: arg, Err: fmt.Errorf("%w: %q", subnetmath.ErrInvalidAddress, text)}
+}
+
+// formatNetwork returns the CIDR notation of the network or an error if it would not be
+// read back as the same network
+func formatNetwork(network *net.IPNet) (string, error) {
+	if network == nil {
+		return "", &subnetmath.ValidationError{Arg: "network", Err: subnetmath.ErrNilNetwork}
+	}
+	text, err := subnetmath.Network{IPNet: network}.MarshalText()
+	return string(text), err
+}
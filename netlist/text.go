@@ -0,0 +1,77 @@
+package netlist
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// TextReader reads a list with one network per line. A line holds CIDR notation, a single
+// address or an inclusive range of addresses such as "10.0.0.1 - 10.0.0.9" which is expanded
+// into one entry per subnet. Everything after a '#' is a comment and blank lines are skipped.
+type TextReader struct {
+	scanner *bufio.Scanner
+	line    int
+	pending []Entry
+}
+
+// NewTextReader returns a TextReader that reads from r
+func NewTextReader(r io.Reader) *TextReader {
+	return &TextReader{scanner: bufio.NewScanner(r)}
+}
+
+// Read returns the next entry or io.EOF once the list is exhausted
+func (r *TextReader) Read() (Entry, error) {
+	for len(r.pending) == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return Entry{}, &ParseError{r.line + 1, err}
+			}
+			return Entry{}, io.EOF
+		}
+		r.line++
+		text, comment, _ := strings.Cut(r.scanner.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		networks, err := parseNetworks(text)
+		if err != nil {
+			return Entry{}, &ParseError{r.line, err}
+		}
+		for _, network := range networks {
+			r.pending = append(r.pending, Entry{Network: network, Line: r.line, Comment: strings.TrimSpace(comment)})
+		}
+	}
+	entry := r.pending[0]
+	r.pending = r.pending[1:]
+	return entry, nil
+}
+
+// TextWriter writes a list with one network per line followed by its comment if any
+type TextWriter struct {
+	w *bufio.Writer
+}
+
+// NewTextWriter returns a TextWriter that writes to w
+func NewTextWriter(w io.Writer) *TextWriter {
+	return &TextWriter{bufio.NewWriter(w)}
+}
+
+// Write writes the network and comment of the entry
+func (w *TextWriter) Write(entry Entry) error {
+	text, err := formatNetwork(entry.Network)
+	if err != nil {
+		return err
+	}
+	if comment := strings.Join(strings.Fields(entry.Comment), " "); comment != "" {
+		text += " # " + comment
+	}
+	_, err = w.w.WriteString(text + "\n")
+	return err
+}
+
+// Close flushes the buffered output
+func (w *TextWriter) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,92 @@
+package netlist
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/demskie/subnetmath"
+)
+
+// entryStrings returns each entry as its network, line number and comment for comparison
+func entryStrings(entries []Entry) []string {
+	output := make([]string, len(entries))
+	for i, entry := range entries {
+		output[i] = strings.TrimSpace(fmt.Sprintf("%v line %d %s", entry.Network, entry.Line, entry.Comment))
+	}
+	return output
+}
+
+func TestTextReader(t *testing.T) {
+	input := strings.Join([]string{
+		"# allocated networks",
+		"192.168.1.0/24   # office",
+		"",
+		"10.0.0.6 - 10.0.0.9",
+		"2001:db8::/32",
+		"  172.16.0.1",
+	}, "\n")
+	output, err := ReadAll(NewTextReader(strings.NewReader(input)))
+	expected := []string{
+		"192.168.1.0/24 line 2 office",
+		"10.0.0.6/31 line 4",
+		"10.0.0.8/31 line 4",
+		"2001:db8::/32 line 5",
+		"172.16.0.1/32 line 6",
+	}
+	if err != nil || strings.Join(entryStrings(output), "\n") != strings.Join(expected, "\n") {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", entryStrings(output), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestTextReaderErrors(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		err   error
+		line  int
+	}{
+		{"10.0.0.0/8\n10.0.0.1/8", subnetmath.ErrHostBitsSet, 2},
+		{"10.0.0.0/8\n\n10.0.0.9 - 10.0.0.1", subnetmath.ErrReversedRange, 3},
+		{"10.0.0.0/8\n# ok\n10.0.0.1 - ::1", subnetmath.ErrMixedFamilies, 3},
+		{"\n\n10.0.0.256", subnetmath.ErrInvalidAddress, 3},
+	} {
+		_, err := ReadNetworks(NewTextReader(strings.NewReader(test.input)))
+		var parseErr *ParseError
+		if !errors.Is(err, test.err) || !errors.As(err, &parseErr) || parseErr.Line != test.line {
+			t.Error(
+				"\n<<<input>>>\n", test.input,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", test.line, test.err,
+			)
+		}
+	}
+}
+
+func TestTextWriter(t *testing.T) {
+	var output strings.Builder
+	writer := NewTextWriter(&output)
+	writer.Write(Entry{Network: subnetmath.ParseNetworkCIDR("10.0.0.0/8"), Comment: "private\nnetwork"})
+	err := WriteNetworks(writer, subnetmath.FindUnusedSubnets(
+		subnetmath.ParseNetworkCIDR("192.168.0.0/22"),
+		subnetmath.ParseNetworkCIDR("192.168.1.0/24"),
+	))
+	expected := "10.0.0.0/8 # private network\n192.168.0.0/24\n192.168.2.0/23\n"
+	if err != nil || output.String() != expected {
+		t.Error(
+			"\n<<<actual_output>>>\n", output.String(), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	if err := writer.Write(Entry{Network: &net.IPNet{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(8, 32)}}); !errors.Is(err, subnetmath.ErrHostBitsSet) {
+		t.Error(
+			"\n<<<actual_output>>>\n", err,
+			"\n<<<expected_output>>>\n", subnetmath.ErrHostBitsSet,
+		)
+	}
+}
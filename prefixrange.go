@@ -0,0 +1,279 @@
+package subnetmath
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPrefixRange is wrapped by the *ValidationError of ParsePrefixRange
+var ErrInvalidPrefixRange = errors.New("invalid prefix range")
+
+// PrefixRange matches the networks within Prefix whose prefix length is between MinLength
+// and MaxLength inclusive, such as "10.0.0.0/8 ge 24 le 28". Lengths are counted within the
+// address family so they are at most 32 for IPv4.
+type PrefixRange struct {
+	Prefix    *net.IPNet
+	MinLength int
+	MaxLength int
+}
+
+// ParsePrefixRange parses a prefix followed by the lengths it matches in the syntax of
+// Cisco ("ge 24 le 28"), Juniper ("prefix-length-range /24-/28", "upto /24", "orlonger",
+// "longer" or "exact") or BIRD ("10.0.0.0/8{24,28}" or "10.0.0.0/8+"). A prefix on its own
+// matches itself exactly.
+func ParsePrefixRange(s string) (PrefixRange, error) {
+	invalid := func(reason string) (PrefixRange, error) {
+		return PrefixRange{}, &ValidationError{"prefix range", fmt.Errorf("%w: %s in %q", ErrInvalidPrefixRange, reason, s)}
+	}
+	fields := strings.Fields(s)
+	if brace := strings.IndexByte(s, '{'); brace >= 0 {
+		fields = []string{strings.TrimSpace(s[:brace]), "{", strings.TrimSpace(s[brace:])}
+	}
+	if len(fields) == 0 {
+		return invalid("missing prefix")
+	}
+	prefixText := fields[0]
+	orLonger := strings.HasSuffix(prefixText, "+")
+	if orLonger {
+		prefixText = strings.TrimSuffix(prefixText, "+")
+	}
+	prefix, err := ParseNetworkCIDRStrict(prefixText)
+	if err != nil {
+		return PrefixRange{}, err
+	}
+	r := PrefixRange{Prefix: prefix}
+	length, bits := r.prefixLength()
+	r.MinLength, r.MaxLength = length, length
+	if orLonger {
+		r.MaxLength = bits
+	}
+	ge, le := -1, -1
+	for i := 1; i < len(fields); i++ {
+		keyword, argument := fields[i], ""
+		if i+1 < len(fields) {
+			argument = fields[i+1]
+		}
+		switch keyword {
+		case "ge", "le", "upto", "prefix-length-range", "{":
+			i++
+		case "exact", "orlonger", "longer":
+		default:
+			return invalid(fmt.Sprintf("unexpected %q", keyword))
+		}
+		var ok bool
+		switch keyword {
+		case "ge":
+			ge, ok = parsePrefixLength(argument)
+		case "le":
+			le, ok = parsePrefixLength(argument)
+		case "upto":
+			r.MaxLength, ok = parsePrefixLength(argument)
+		case "prefix-length-range":
+			minText, maxText, _ := strings.Cut(argument, "-")
+			r.MinLength, ok = parsePrefixLength(minText)
+			if ok {
+				r.MaxLength, ok = parsePrefixLength(maxText)
+			}
+		case "{":
+			minText, maxText, found := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(argument, "{"), "}"), ",")
+			r.MinLength, ok = parsePrefixLength(strings.TrimSpace(minText))
+			if ok {
+				r.MaxLength, ok = parsePrefixLength(strings.TrimSpace(maxText))
+			}
+			ok = ok && found && strings.HasSuffix(argument, "}")
+		case "exact":
+			ok = true
+		case "orlonger":
+			r.MaxLength, ok = bits, true
+		case "longer":
+			r.MinLength, r.MaxLength, ok = length+1, bits, true
+		}
+		if !ok {
+			return invalid(fmt.Sprintf("invalid %q", strings.TrimSpace(keyword+" "+argument)))
+		}
+	}
+	// ge and le behave as they do in a Cisco prefix-list
+	switch {
+	case ge >= 0 && le >= 0:
+		r.MinLength, r.MaxLength = ge, le
+	case ge >= 0:
+		r.MinLength, r.MaxLength = ge, bits
+	case le >= 0:
+		r.MaxLength = le
+	}
+	if r.MinLength < length || r.MinLength > r.MaxLength || r.MaxLength > bits {
+		return invalid("lengths out of range")
+	}
+	return r, nil
+}
+
+// parsePrefixLength parses a length with an optional leading slash
+func parsePrefixLength(s string) (int, bool) {
+	length, err := strconv.Atoi(strings.TrimPrefix(s, "/"))
+	return length, err == nil && length >= 0
+}
+
+// prefixLength returns the length of the prefix within its family along with the
+// number of bits of the family
+func (r PrefixRange) prefixLength() (length, bits int) {
+	return familyPrefixLength(r.Prefix)
+}
+
+// familyPrefixLength returns the length of a valid network within its family so that an
+// IPv4-mapped /104 is a /8, along with the number of bits of the family
+func familyPrefixLength(network *net.IPNet) (length, bits int) {
+	ones, maskBits := network.Mask.Size()
+	bits = familyBits(AddrFamily(network.IP))
+	return ones - (maskBits - bits), bits
+}
+
+// Matches reports whether the network is within the prefix and its prefix length is
+// within the range. False is returned for invalid networks and other address families.
+func (r PrefixRange) Matches(network *net.IPNet) bool {
+	if r.Prefix == nil || network == nil || !MaskIsContiguous(network.Mask) ||
+		AddrFamily(network.IP) != AddrFamily(r.Prefix.IP) {
+		return false
+	}
+	length, _ := familyPrefixLength(network)
+	return length >= r.MinLength && length <= r.MaxLength && NetworkContainsSubnet(r.Prefix, network)
+}
+
+// String returns the range in the syntax of a Cisco prefix-list
+func (r PrefixRange) String() string {
+	if r.Prefix == nil {
+		return "<nil>"
+	}
+	length, bits := r.prefixLength()
+	s := r.Prefix.String()
+	impliedMax := length
+	if r.MinLength != length {
+		s += " ge " + strconv.Itoa(r.MinLength)
+		impliedMax = bits
+	}
+	if r.MaxLength != impliedMax {
+		s += " le " + strconv.Itoa(r.MaxLength)
+	}
+	return s
+}
+
+// PrefixListEntry is a PrefixRange and whether the networks it matches are permitted
+type PrefixListEntry struct {
+	Permit bool
+	Range  PrefixRange
+}
+
+// ParsePrefixListEntry parses "permit" or "deny" followed by a prefix range, see ParsePrefixRange
+func ParsePrefixListEntry(s string) (PrefixListEntry, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || fields[0] != "permit" && fields[0] != "deny" {
+		return PrefixListEntry{}, &ValidationError{"prefix list entry",
+			fmt.Errorf("%w: expected permit or deny in %q", ErrInvalidPrefixRange, s)}
+	}
+	r, err := ParsePrefixRange(strings.Join(fields[1:], " "))
+	return PrefixListEntry{fields[0] == "permit", r}, err
+}
+
+func (e PrefixListEntry) String() string {
+	if e.Permit {
+		return "permit " + e.Range.String()
+	}
+	return "deny " + e.Range.String()
+}
+
+// PrefixList is an ordered list of entries where the first entry to match a network decides
+// whether it is permitted. Networks that no entry matches are denied. Entries are indexed
+// by a binary trie of their prefixes so that evaluation only considers entries whose prefix
+// contains the network.
+type PrefixList struct {
+	entries []PrefixListEntry
+	roots   [2]*prefixTrieNode
+}
+
+type prefixTrieNode struct {
+	children [2]*prefixTrieNode
+	// entries holds the ascending indices of the entries with the prefix of this node
+	entries []int
+}
+
+// NewPrefixList returns a PrefixList of the entries in order
+func NewPrefixList(entries ...PrefixListEntry) *PrefixList {
+	l := &PrefixList{}
+	for _, entry := range entries {
+		l.Add(entry)
+	}
+	return l
+}
+
+// Add appends the entry to the end of the list. Entries with an invalid prefix never match.
+func (l *PrefixList) Add(entry PrefixListEntry) {
+	l.entries = append(l.entries, entry)
+	first, _, family := uint128Range(entry.Range.Prefix)
+	if family == 0 {
+		return
+	}
+	length, bits := familyPrefixLength(entry.Range.Prefix)
+	node := l.root(family, true)
+	for depth := 0; depth < length; depth++ {
+		bit := first.bit(bits - 1 - depth)
+		if node.children[bit] == nil {
+			node.children[bit] = &prefixTrieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.entries = append(node.entries, len(l.entries)-1)
+}
+
+func (l *PrefixList) root(family Family, create bool) *prefixTrieNode {
+	i := 0
+	if family == IPv6 {
+		i = 1
+	}
+	if l.roots[i] == nil && create {
+		l.roots[i] = &prefixTrieNode{}
+	}
+	return l.roots[i]
+}
+
+// Entries returns the entries of the list in order
+func (l *PrefixList) Entries() []PrefixListEntry {
+	return append([]PrefixListEntry{}, l.entries...)
+}
+
+// Evaluate returns whether the network is permitted along with the index of the first
+// entry that matched it or -1 if no entry matched
+func (l *PrefixList) Evaluate(network *net.IPNet) (permit bool, index int) {
+	first, _, family := uint128Range(network)
+	if family == 0 {
+		return false, -1
+	}
+	length, bits := familyPrefixLength(network)
+	index = -1
+	for node, depth := l.root(family, false), 0; node != nil; depth++ {
+		for _, i := range node.entries {
+			if index >= 0 && i >= index {
+				break
+			}
+			if r := l.entries[i].Range; length >= r.MinLength && length <= r.MaxLength {
+				index = i
+				break
+			}
+		}
+		if depth == length {
+			break
+		}
+		node = node.children[first.bit(bits-1-depth)]
+	}
+	if index < 0 {
+		return false, -1
+	}
+	return l.entries[index].Permit, index
+}
+
+// Permits reports whether the network is permitted by the list
+func (l *PrefixList) Permits(network *net.IPNet) bool {
+	permit, _ := l.Evaluate(network)
+	return permit
+}
@@ -0,0 +1,159 @@
+package subnetmath
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func TestParsePrefixRange(t *testing.T) {
+	for input, expected := range map[string]string{
+		"10.0.0.0/8":                                 "10.0.0.0/8",
+		"10.0.0.0/8 ge 24 le 28":                     "10.0.0.0/8 ge 24 le 28",
+		"10.0.0.0/8 le 28 ge 24":                     "10.0.0.0/8 ge 24 le 28",
+		"10.0.0.0/8 ge 24":                           "10.0.0.0/8 ge 24",
+		"10.0.0.0/8 le 24":                           "10.0.0.0/8 le 24",
+		"10.0.0.0/8 ge 24 le 24":                     "10.0.0.0/8 ge 24 le 24",
+		"10.0.0.0/8 exact":                           "10.0.0.0/8",
+		"10.0.0.0/8 orlonger":                        "10.0.0.0/8 le 32",
+		"10.0.0.0/8 longer":                          "10.0.0.0/8 ge 9",
+		"10.0.0.0/8 upto /24":                        "10.0.0.0/8 le 24",
+		"10.0.0.0/8 prefix-length-range /24-/28":     "10.0.0.0/8 ge 24 le 28",
+		"10.0.0.0/8{24,28}":                          "10.0.0.0/8 ge 24 le 28",
+		"10.0.0.0/8 { 24, 28 }":                      "10.0.0.0/8 ge 24 le 28",
+		"10.0.0.0/8+":                                "10.0.0.0/8 le 32",
+		"2001:db8::/32 ge 48 le 64":                  "2001:db8::/32 ge 48 le 64",
+		"2001:db8::/32 prefix-length-range /48-/128": "2001:db8::/32 ge 48",
+	} {
+		output, err := ParsePrefixRange(input)
+		if err != nil || output.String() != expected {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", output, err,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+		roundTrip, err := ParsePrefixRange(output.String())
+		if err != nil || roundTrip.MinLength != output.MinLength || roundTrip.MaxLength != output.MaxLength {
+			t.Error(
+				"\n<<<input>>>\n", output,
+				"\n<<<actual_output>>>\n", roundTrip, err,
+				"\n<<<expected_output>>>\n", output,
+			)
+		}
+	}
+}
+
+func TestParsePrefixRangeErrors(t *testing.T) {
+	for input, expected := range map[string]error{
+		"":                       ErrInvalidPrefixRange,
+		"10.0.0.0/8 ge 4":        ErrInvalidPrefixRange,
+		"10.0.0.0/8 ge 28 le 24": ErrInvalidPrefixRange,
+		"10.0.0.0/8 le 33":       ErrInvalidPrefixRange,
+		"10.0.0.0/8 ge":          ErrInvalidPrefixRange,
+		"10.0.0.0/8 between 1 2": ErrInvalidPrefixRange,
+		"10.0.0.0/8{24,28":       ErrInvalidPrefixRange,
+		"10.0.0.1/8 ge 24":       ErrHostBitsSet,
+		"10.0.0.0/33":            ErrInvalidCIDR,
+	} {
+		if _, err := ParsePrefixRange(input); !errors.Is(err, expected) {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
+
+func TestPrefixRangeMatches(t *testing.T) {
+	r, _ := ParsePrefixRange("10.0.0.0/8 ge 24 le 28")
+	for input, expected := range map[string]bool{
+		"10.0.0.0/8":          false,
+		"10.1.2.0/24":         true,
+		"10.1.2.16/28":        true,
+		"10.1.2.16/29":        false,
+		"11.1.2.0/24":         false,
+		"::ffff:10.1.2.0/120": true,
+		"2001:db8::/32":       false,
+	} {
+		if output := r.Matches(ParseNetworkCIDR(input)); output != expected {
+			t.Error(
+				"\n<<<input>>>\n", r, input,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
+
+func TestPrefixList(t *testing.T) {
+	var entries []PrefixListEntry
+	for _, line := range []string{
+		"deny 10.1.0.0/16 le 32",
+		"permit 10.0.0.0/8 ge 24 le 28",
+		"permit 0.0.0.0/0",
+		"permit 2001:db8::/32 ge 48",
+	} {
+		entry, err := ParsePrefixListEntry(line)
+		if err != nil || entry.String() != line {
+			t.Fatal(line, entry, err)
+		}
+		entries = append(entries, entry)
+	}
+	list := NewPrefixList(entries...)
+	for input, expected := range map[string]int{
+		"10.1.2.0/24":     0,
+		"10.2.2.0/24":     1,
+		"10.2.2.0/23":     -1,
+		"0.0.0.0/0":       2,
+		"2001:db8:1::/48": 3,
+		"2001:db8::/32":   -1,
+	} {
+		permit, index := list.Evaluate(ParseNetworkCIDR(input))
+		if index != expected || permit != (index > 0) {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", permit, index,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
+
+// TestPrefixListLinearScan compares the trie against evaluating every entry in order
+func TestPrefixListLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomNetwork := func() *net.IPNet {
+		ip := net.IP{10, byte(rng.Intn(4)), byte(rng.Intn(256)), byte(rng.Intn(256))}
+		mask := net.CIDRMask(rng.Intn(33), 32)
+		return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	}
+	list := NewPrefixList()
+	for i := 0; i < 200; i++ {
+		prefix := randomNetwork()
+		length, _ := prefix.Mask.Size()
+		minLength := length + rng.Intn(33-length)
+		maxLength := minLength + rng.Intn(33-minLength)
+		list.Add(PrefixListEntry{rng.Intn(2) == 0, PrefixRange{prefix, minLength, maxLength}})
+	}
+	entries := list.Entries()
+	for i := 0; i < 2000; i++ {
+		network := randomNetwork()
+		expected := -1
+		for j, entry := range entries {
+			if entry.Range.Matches(network) {
+				expected = j
+				break
+			}
+		}
+		if _, index := list.Evaluate(network); index != expected {
+			t.Fatal(
+				"\n<<<input>>>\n", network,
+				"\n<<<actual_output>>>\n", index,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package subnetmath
+
+// raceEnabled is set when testing with the race detector, which makes sync.Pool drop items
+const raceEnabled = true
@@ -0,0 +1,95 @@
+package subnetmath
+
+import (
+	"math/big"
+	"math/rand"
+	"net"
+)
+
+// RandomAddr returns an address of the network chosen uniformly at random with the source.
+// The same seeded source always results in the same address. Nil is returned if the network
+// isn't a valid CIDR network or the source is nil.
+func RandomAddr(network *net.IPNet, src rand.Source) net.IP {
+	first, _, family := uint128Range(network)
+	if family == 0 || src == nil || !MaskIsContiguous(network.Mask) {
+		return nil
+	}
+	ones, bits := familyPrefixLength(network)
+	return uint128Addr(first.or(randomUint128(rand.New(src), bits-ones)), family)
+}
+
+// RandomSubnet returns a subnet of the aggregate with the prefix length chosen uniformly at
+// random with the source. The prefix length is counted within the address family so it is
+// at most 32 for IPv4. Nil is returned if the aggregate is invalid, the prefix length is
+// shorter than the aggregate or longer than the family allows, or the source is nil.
+func RandomSubnet(aggregate *net.IPNet, prefixLength int, src rand.Source) *net.IPNet {
+	first, _, family := uint128Range(aggregate)
+	if family == 0 || src == nil || !MaskIsContiguous(aggregate.Mask) {
+		return nil
+	}
+	ones, bits := familyPrefixLength(aggregate)
+	if prefixLength < ones || prefixLength > bits {
+		return nil
+	}
+	offset := randomUint128(rand.New(src), prefixLength-ones).lsh(bits - prefixLength)
+	return appendNetworkPointer(nil, first.or(offset), prefixLength, family)[0]
+}
+
+// RandomFreeSubnet returns a subnet of the aggregate with the prefix length that doesn't
+// overlap any of the used subnets, chosen uniformly at random among every such subnet with
+// the source. Free space is found the same way as FindUnusedSubnets. Nil is returned if no
+// such subnet exists or for the same reasons as RandomSubnet.
+func RandomFreeSubnet(aggregate *net.IPNet, prefixLength int, src rand.Source, used ...*net.IPNet) *net.IPNet {
+	_, _, family := uint128Range(aggregate)
+	if family == 0 || src == nil || !MaskIsContiguous(aggregate.Mask) {
+		return nil
+	}
+	ones, bits := familyPrefixLength(aggregate)
+	if prefixLength < ones || prefixLength > bits {
+		return nil
+	}
+	// each free block of the prefix length or shorter holds 2^(prefixLength-blockOnes) candidates
+	type block struct {
+		first      uint128
+		candidates *big.Int
+	}
+	var blocks []block
+	total := new(big.Int)
+	walkUnusedSubnets(aggregate, used, func(addr uint128, blockOnes int, _ Family) bool {
+		if blockOnes <= prefixLength {
+			candidates := new(big.Int).Lsh(bigOne, uint(prefixLength-blockOnes))
+			blocks = append(blocks, block{addr, candidates})
+			total.Add(total, candidates)
+		}
+		return true
+	})
+	if len(blocks) == 0 {
+		return nil
+	}
+	choice := new(big.Int).Rand(rand.New(src), total)
+	for _, b := range blocks {
+		if choice.Cmp(b.candidates) < 0 {
+			offset := uint128{new(big.Int).Rsh(choice, 64).Uint64(), choice.Uint64()}
+			return appendNetworkPointer(nil, b.first.or(offset.lsh(bits-prefixLength)), prefixLength, family)[0]
+		}
+		choice.Sub(choice, b.candidates)
+	}
+	return nil
+}
+
+// randomUint128 returns an integer whose lowest n bits are uniformly random
+func randomUint128(rnd *rand.Rand, n int) uint128 {
+	return uint128{rnd.Uint64(), rnd.Uint64()}.and(uint128Ones(n))
+}
+
+// uint128Addr returns the 16 byte form of the address
+func uint128Addr(addr uint128, family Family) net.IP {
+	if family == IPv4 {
+		address := make(net.IP, net.IPv4len)
+		addr.putAddr(address)
+		return address.To16()
+	}
+	address := make(net.IP, net.IPv6len)
+	addr.putAddr(address)
+	return address
+}
@@ -0,0 +1,134 @@
+package subnetmath
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func TestRandomAddr(t *testing.T) {
+	for _, input := range []string{"192.168.0.0/30", "2001:db8::/64", "::/0", "10.0.0.1/32"} {
+		network := ParseNetworkCIDR(input)
+		src := rand.NewSource(1)
+		seen := map[string]bool{}
+		for i := 0; i < 200; i++ {
+			address := RandomAddr(network, src)
+			if len(address) != net.IPv6len || !network.Contains(address) {
+				t.Fatal(
+					"\n<<<input>>>\n", input,
+					"\n<<<actual_output>>>\n", address,
+					"\n<<<expected_output>>>\n", "an address within the network",
+				)
+			}
+			seen[address.String()] = true
+		}
+		expected := 200
+		if size := addressCount(network); size.IsInt64() && size.Int64() < 200 {
+			expected = int(size.Int64())
+		}
+		if len(seen) != expected {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", len(seen),
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+		if first, again := RandomAddr(network, rand.NewSource(7)), RandomAddr(network, rand.NewSource(7)); !first.Equal(again) {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", first, again,
+				"\n<<<expected_output>>>\n", "the same address for the same seed",
+			)
+		}
+	}
+	if output := RandomAddr(nil, rand.NewSource(1)); output != nil {
+		t.Error(
+			"\n<<<input>>>\n", nil,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", nil,
+		)
+	}
+}
+
+func TestRandomSubnet(t *testing.T) {
+	for _, test := range []struct {
+		aggregate    string
+		prefixLength int
+	}{
+		{"10.0.0.0/8", 24},
+		{"10.0.0.0/8", 8},
+		{"2001:db8::/32", 64},
+		{"2001:db8::/32", 128},
+		{"::/0", 1},
+	} {
+		aggregate := ParseNetworkCIDR(test.aggregate)
+		src := rand.NewSource(1)
+		for i := 0; i < 50; i++ {
+			subnet := RandomSubnet(aggregate, test.prefixLength, src)
+			ones, _ := subnet.Mask.Size()
+			if ones != test.prefixLength || !NetworkContainsSubnet(aggregate, subnet) ||
+				ValidateNetwork(subnet) != nil {
+				t.Fatal(
+					"\n<<<input>>>\n", test.aggregate, test.prefixLength,
+					"\n<<<actual_output>>>\n", subnet,
+					"\n<<<expected_output>>>\n", "a subnet of the aggregate",
+				)
+			}
+		}
+	}
+	for _, prefixLength := range []int{7, 33} {
+		if output := RandomSubnet(ParseNetworkCIDR("10.0.0.0/8"), prefixLength, rand.NewSource(1)); output != nil {
+			t.Error(
+				"\n<<<input>>>\n", "10.0.0.0/8", prefixLength,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", nil,
+			)
+		}
+	}
+}
+
+func TestRandomFreeSubnet(t *testing.T) {
+	aggregate := ParseNetworkCIDR("10.0.0.0/24")
+	used := []*net.IPNet{ParseNetworkCIDR("10.0.0.64/26"), ParseNetworkCIDR("10.0.0.200/32")}
+	// free space is 10.0.0.0/26, 10.0.0.128/26 and the blocks around 10.0.0.200
+	src := rand.NewSource(1)
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		counts[RandomFreeSubnet(aggregate, 26, src, used...).String()]++
+	}
+	if len(counts) != 2 || counts["10.0.0.0/26"] < 1350 || counts["10.0.0.128/26"] < 1350 {
+		t.Error(
+			"\n<<<input>>>\n", aggregate, used, 26,
+			"\n<<<actual_output>>>\n", counts,
+			"\n<<<expected_output>>>\n", "10.0.0.0/26 and 10.0.0.128/26 about 1500 times each",
+		)
+	}
+	for i := 0; i < 500; i++ {
+		subnet := RandomFreeSubnet(aggregate, 30, src, used...)
+		if subnet == nil || !NetworkContainsSubnet(aggregate, subnet) || NetworkContainsSubnet(subnet, used[1]) ||
+			NetworkContainsSubnet(used[0], subnet) {
+			t.Fatal(
+				"\n<<<input>>>\n", aggregate, used, 30,
+				"\n<<<actual_output>>>\n", subnet,
+				"\n<<<expected_output>>>\n", "a free /30",
+			)
+		}
+	}
+	full := ParseNetworkCIDR("10.0.0.0/25")
+	if output := RandomFreeSubnet(full, 26, src, ParseNetworkCIDR("10.0.0.0/26"), ParseNetworkCIDR("10.0.0.127/32")); output != nil {
+		t.Error(
+			"\n<<<input>>>\n", full, 26,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", nil,
+		)
+	}
+	ipv6 := ParseNetworkCIDR("2001:db8::/48")
+	subnet := RandomFreeSubnet(ipv6, 64, rand.NewSource(3), ParseNetworkCIDR("2001:db8::/49"))
+	if !NetworkContainsSubnet(ParseNetworkCIDR("2001:db8:0:8000::/49"), subnet) {
+		t.Error(
+			"\n<<<input>>>\n", ipv6, 64,
+			"\n<<<actual_output>>>\n", subnet,
+			"\n<<<expected_output>>>\n", "a /64 within 2001:db8:0:8000::/49",
+		)
+	}
+}
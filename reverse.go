@@ -0,0 +1,101 @@
+package subnetmath
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// PTRName returns the in-addr.arpa or ip6.arpa name of the address or an empty string
+// if the address is invalid
+func PTRName(address net.IP) string {
+	if v4addr := address.To4(); v4addr != nil {
+		return reverseName(octetLabels(v4addr, net.IPv4len), "in-addr.arpa")
+	}
+	if v6addr := address.To16(); v6addr != nil {
+		return reverseName(nibbleLabels(v6addr, 2*net.IPv6len), "ip6.arpa")
+	}
+	return ""
+}
+
+// ReverseZones returns the reverse DNS zones required to delegate the network. IPv4
+// prefixes shorter than /24 that aren't octet aligned are split into multiple zones
+// while prefixes of /25 through /32 use RFC 2317 classless delegation names such as
+// "128/26.2.0.192.in-addr.arpa". IPv6 prefixes are split on nibble boundaries.
+func ReverseZones(network *net.IPNet) []string {
+	if network == nil {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if bits == 0 {
+		return nil
+	}
+	if v4addr := network.IP.To4(); v4addr != nil && bits == 32 {
+		v4addr = v4addr.Mask(network.Mask)
+		if ones > 24 {
+			labels := octetLabels(v4addr, 3)
+			classless := strconv.Itoa(int(v4addr[3])) + "/" + strconv.Itoa(ones)
+			return []string{classless + "." + reverseName(labels, "in-addr.arpa")}
+		}
+		return expandReverseZones(v4addr, ones, 8, octetLabels, "in-addr.arpa")
+	}
+	if v6addr := network.IP.To16(); v6addr != nil && bits == 128 {
+		v6addr = v6addr.Mask(network.Mask)
+		return expandReverseZones(v6addr, ones, 4, nibbleLabels, "ip6.arpa")
+	}
+	return nil
+}
+
+// expandReverseZones rounds the prefix up to the next label boundary and returns
+// a zone for each of the resulting networks. Only the last label ever varies.
+func expandReverseZones(address net.IP, ones, labelBits int,
+	labelFunc func(net.IP, int) []string, suffix string) []string {
+	count := (ones + labelBits - 1) / labelBits
+	if count == 0 {
+		return []string{suffix}
+	}
+	radix := 16
+	if labelBits == 8 {
+		radix = 10
+	}
+	labels := labelFunc(address, count)
+	base, _ := strconv.ParseUint(labels[count-1], radix, 8)
+	zones := make([]string, 0, 1<<uint(count*labelBits-ones))
+	for i := uint64(0); i < 1<<uint(count*labelBits-ones); i++ {
+		labels[count-1] = strconv.FormatUint(base+i, radix)
+		zones = append(zones, reverseName(labels, suffix))
+	}
+	return zones
+}
+
+func octetLabels(address net.IP, count int) []string {
+	labels := make([]string, count)
+	for i := range labels {
+		labels[i] = strconv.Itoa(int(address[i]))
+	}
+	return labels
+}
+
+func nibbleLabels(address net.IP, count int) []string {
+	labels := make([]string, count)
+	for i := range labels {
+		nibble := address[i/2] >> 4
+		if i%2 == 1 {
+			nibble = address[i/2] & 0x0f
+		}
+		labels[i] = hexDigits[nibble : nibble+1]
+	}
+	return labels
+}
+
+func reverseName(labels []string, suffix string) string {
+	var builder strings.Builder
+	for i := len(labels) - 1; i >= 0; i-- {
+		builder.WriteString(labels[i])
+		builder.WriteByte('.')
+	}
+	builder.WriteString(suffix)
+	return builder.String()
+}
@@ -0,0 +1,59 @@
+package subnetmath
+
+import (
+	"net"
+	"testing"
+)
+
+func TestPTRName(t *testing.T) {
+	inputs := []string{"192.0.2.5", "2001:db8::567:89ab"}
+	expected := []string{
+		"5.2.0.192.in-addr.arpa",
+		"b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+	}
+	for i := range inputs {
+		actualOutput := PTRName(net.ParseIP(inputs[i]))
+		if actualOutput != expected[i] {
+			t.Error("\n",
+				"<<<input>>>\n", inputs[i],
+				"\n<<<actual_output>>>\n", actualOutput,
+				"\n<<<expected_output>>>\n", expected[i],
+			)
+		}
+	}
+}
+
+func TestReverseZones(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"10.0.0.0/8", []string{"10.in-addr.arpa"}},
+		{"192.168.0.0/24", []string{"0.168.192.in-addr.arpa"}},
+		{"192.168.4.0/22", []string{
+			"4.168.192.in-addr.arpa",
+			"5.168.192.in-addr.arpa",
+			"6.168.192.in-addr.arpa",
+			"7.168.192.in-addr.arpa",
+		}},
+		{"192.0.2.128/26", []string{"128/26.2.0.192.in-addr.arpa"}},
+		{"0.0.0.0/0", []string{"in-addr.arpa"}},
+		{"2001:db8::/32", []string{"8.b.d.0.1.0.0.2.ip6.arpa"}},
+		{"2001:db8::/31", []string{"8.b.d.0.1.0.0.2.ip6.arpa", "9.b.d.0.1.0.0.2.ip6.arpa"}},
+		{"2001:db8:a0::/43", []string{"a.0.0.8.b.d.0.1.0.0.2.ip6.arpa", "b.0.0.8.b.d.0.1.0.0.2.ip6.arpa"}},
+	}
+	for _, test := range tests {
+		output := ReverseZones(ParseNetworkCIDR(test.input))
+		equal := len(output) == len(test.expected)
+		for i := 0; equal && i < len(output); i++ {
+			equal = output[i] == test.expected[i]
+		}
+		if !equal {
+			t.Error("\n",
+				"<<<input>>>\n", test.input,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package rib simulates a routing table so that network designs can be tested offline.
+// Routes are selected by administrative distance and metric, next hops are resolved
+// recursively, and destinations are forwarded by longest prefix match.
+package rib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+
+	"github.com/demskie/subnetmath"
+)
+
+// Route is a prefix reachable through a next hop. A nil NextHop is a directly connected
+// route which is always reachable.
+type Route struct {
+	Prefix        *net.IPNet
+	NextHop       net.IP
+	Metric        int
+	AdminDistance int
+}
+
+func (r Route) String() string {
+	if r.NextHop == nil {
+		return fmt.Sprintf("%v connected [%d/%d]", r.Prefix, r.AdminDistance, r.Metric)
+	}
+	return fmt.Sprintf("%v via %v [%d/%d]", r.Prefix, r.NextHop, r.AdminDistance, r.Metric)
+}
+
+// Status describes whether a route is used for forwarding
+type Status int
+
+// route statuses
+const (
+	// Active routes are the best reachable route for their prefix
+	Active Status = iota
+	// Shadowed routes are active but every address of their prefix is covered by more
+	// specific active routes so they never forward anything
+	Shadowed
+	// Inactive routes are reachable but a route for the same prefix is preferred
+	Inactive
+	// Unreachable routes have a next hop that doesn't resolve through any reachable route
+	Unreachable
+)
+
+func (s Status) String() string {
+	switch s {
+	case Active:
+		return "active"
+	case Shadowed:
+		return "shadowed"
+	case Inactive:
+		return "inactive"
+	case Unreachable:
+		return "unreachable"
+	}
+	return "unknown"
+}
+
+// Entry is a route of the table along with its status
+type Entry struct {
+	Route
+	Status Status
+}
+
+func (e Entry) String() string {
+	return e.Route.String() + " " + e.Status.String()
+}
+
+// Table is a routing table. The status of every route is recomputed after routes are added
+// so a Table must not be modified while it is being read, but it may be read concurrently.
+type Table struct {
+	// mu guards the analysis which the first read after routes are added computes
+	mu       sync.Mutex
+	routes   []Route
+	roots    [2]*node
+	analyzed bool
+	status   []Status
+}
+
+// node is a binary trie node holding the routes whose prefix is the path to the node
+type node struct {
+	children [2]*node
+	routes   []int
+	// installed is the index of the active route or -1
+	installed int
+}
+
+// NewTable returns a Table holding the routes. An error is returned for the first
+// invalid route, see Table.Add.
+func NewTable(routes ...Route) (*Table, error) {
+	t := &Table{}
+	for _, route := range routes {
+		if err := t.Add(route); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Add adds a copy of the route to the table. A *subnetmath.ValidationError is returned if
+// the prefix is invalid or the next hop is invalid or of a different family.
+func (t *Table) Add(route Route) error {
+	if err := subnetmath.ValidateNetwork(route.Prefix); err != nil {
+		return err
+	}
+	family := subnetmath.AddrFamily(route.Prefix.IP)
+	if route.NextHop != nil {
+		switch nextHopFamily := subnetmath.AddrFamily(route.NextHop); {
+		case nextHopFamily == 0:
+			return &subnetmath.ValidationError{Arg: "next hop", Err: subnetmath.ErrInvalidAddress}
+		case nextHopFamily != family:
+			return &subnetmath.ValidationError{Arg: "next hop", Err: fmt.Errorf("%w: %v next hop %v for %v prefix %v",
+				subnetmath.ErrMixedFamilies, nextHopFamily, route.NextHop, family, route.Prefix)}
+		}
+		route.NextHop = subnetmath.DuplicateAddr(route.NextHop.To16())
+	}
+	route.Prefix = subnetmath.ParseNetworkCIDR(route.Prefix.String())
+	t.routes = append(t.routes, route)
+	n := t.root(family, true)
+	length, _ := route.Prefix.Mask.Size()
+	for depth := 0; depth < length; depth++ {
+		b := addrBit(route.Prefix.IP, depth)
+		if n.children[b] == nil {
+			n.children[b] = &node{installed: -1}
+		}
+		n = n.children[b]
+	}
+	n.routes = append(n.routes, len(t.routes)-1)
+	t.analyzed = false
+	return nil
+}
+
+func (t *Table) root(family subnetmath.Family, create bool) *node {
+	i := 0
+	if family == subnetmath.IPv6 {
+		i = 1
+	}
+	if t.roots[i] == nil && create {
+		t.roots[i] = &node{installed: -1}
+	}
+	return t.roots[i]
+}
+
+// addrBit returns the bit of the address at the position counting from the most
+// significant bit of its family
+func addrBit(address net.IP, position int) int {
+	if v4addr := address.To4(); v4addr != nil {
+		address = v4addr
+	}
+	return int(address[position/8]>>(7-uint(position%8))) & 1
+}
+
+// preferred reports whether the first route is preferred over the second by administrative
+// distance, then metric and then the lowest next hop with connected routes first
+func (t *Table) preferred(first, second int) bool {
+	alpha, bravo := t.routes[first], t.routes[second]
+	switch {
+	case alpha.AdminDistance != bravo.AdminDistance:
+		return alpha.AdminDistance < bravo.AdminDistance
+	case alpha.Metric != bravo.Metric:
+		return alpha.Metric < bravo.Metric
+	case (alpha.NextHop == nil) != (bravo.NextHop == nil):
+		return alpha.NextHop == nil
+	case alpha.NextHop != nil && !alpha.NextHop.Equal(bravo.NextHop):
+		return subnetmath.AddressComesBefore(alpha.NextHop, bravo.NextHop)
+	}
+	return first < second
+}
+
+// walk calls visit with each node whose prefix contains the address from the shortest
+// prefix to the longest
+func (t *Table) walk(address net.IP, visit func(*node)) {
+	family := subnetmath.AddrFamily(address)
+	if family == 0 {
+		return
+	}
+	bits := 32
+	if family == subnetmath.IPv6 {
+		bits = 128
+	}
+	for n, depth := t.root(family, false), 0; n != nil; depth++ {
+		visit(n)
+		if depth == bits {
+			return
+		}
+		n = n.children[addrBit(address, depth)]
+	}
+}
+
+// analyze computes the status of every route unless it is up to date. Next hops are
+// resolved through the longest prefix with a reachable route until no further routes
+// become reachable, the best reachable route of each prefix is installed and installed
+// routes covered by more specific installed routes are shadowed.
+func (t *Table) analyze() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.analyzed {
+		return
+	}
+	t.analyzed = true
+	reachable := make([]bool, len(t.routes))
+	for i, route := range t.routes {
+		reachable[i] = route.NextHop == nil
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, route := range t.routes {
+			if reachable[i] {
+				continue
+			}
+			// the next hop resolves through the longest prefix with a reachable route as
+			// unresolved routes are skipped
+			var resolving *node
+			t.walk(route.NextHop, func(n *node) {
+				for _, j := range n.routes {
+					if reachable[j] {
+						resolving = n
+					}
+				}
+			})
+			reachable[i] = resolving != nil
+			changed = changed || reachable[i]
+		}
+	}
+	t.status = make([]Status, len(t.routes))
+	var nodes []*node
+	for _, root := range t.roots {
+		nodes = appendNodes(nodes, root)
+	}
+	for _, n := range nodes {
+		n.installed = -1
+		for _, i := range n.routes {
+			switch {
+			case !reachable[i]:
+				t.status[i] = Unreachable
+			case n.installed < 0 || t.preferred(i, n.installed):
+				if n.installed >= 0 {
+					t.status[n.installed] = Inactive
+				}
+				n.installed = i
+				t.status[i] = Active
+			default:
+				t.status[i] = Inactive
+			}
+		}
+	}
+	for _, n := range nodes {
+		if n.installed < 0 {
+			continue
+		}
+		var covering []*net.IPNet
+		for _, child := range n.children {
+			covering = t.appendInstalled(covering, child)
+		}
+		if len(covering) > 0 && len(subnetmath.AppendUnusedSubnets(nil, t.routes[n.installed].Prefix, covering...)) == 0 {
+			t.status[n.installed] = Shadowed
+		}
+	}
+}
+
+func appendNodes(nodes []*node, n *node) []*node {
+	if n == nil {
+		return nodes
+	}
+	nodes = append(nodes, n)
+	return appendNodes(appendNodes(nodes, n.children[0]), n.children[1])
+}
+
+// appendInstalled appends the prefixes of the shortest installed routes within the subtree
+func (t *Table) appendInstalled(prefixes []*net.IPNet, n *node) []*net.IPNet {
+	if n == nil {
+		return prefixes
+	}
+	if n.installed >= 0 {
+		return append(prefixes, t.routes[n.installed].Prefix)
+	}
+	return t.appendInstalled(t.appendInstalled(prefixes, n.children[0]), n.children[1])
+}
+
+// Lookup returns the active route with the longest prefix containing the destination
+func (t *Table) Lookup(destination net.IP) (Route, bool) {
+	t.analyze()
+	installed := -1
+	t.walk(destination, func(n *node) {
+		if n.installed >= 0 {
+			installed = n.installed
+		}
+	})
+	if installed < 0 {
+		return Route{}, false
+	}
+	return t.routes[installed], true
+}
+
+// Best returns the active route of the prefix
+func (t *Table) Best(prefix *net.IPNet) (Route, bool) {
+	t.analyze()
+	if subnetmath.ValidateNetwork(prefix) != nil {
+		return Route{}, false
+	}
+	length, _ := subnetmath.ParseNetworkCIDR(prefix.String()).Mask.Size()
+	installed := -1
+	depth := 0
+	t.walk(prefix.IP, func(n *node) {
+		if depth == length {
+			installed = n.installed
+		}
+		depth++
+	})
+	if installed < 0 {
+		return Route{}, false
+	}
+	return t.routes[installed], true
+}
+
+// Entries returns every route with its status in a deterministic order so that tables can
+// be compared. Routes are ordered by prefix with IPv4 first and then by preference.
+func (t *Table) Entries() []Entry {
+	t.analyze()
+	order := make([]int, len(t.routes))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(first, second int) int {
+		alpha, bravo := t.routes[first].Prefix, t.routes[second].Prefix
+		switch {
+		case subnetmath.NetworkComesBefore(alpha, bravo):
+			return -1
+		case subnetmath.NetworkComesBefore(bravo, alpha):
+			return 1
+		case t.preferred(first, second):
+			return -1
+		case t.preferred(second, first):
+			return 1
+		}
+		return 0
+	})
+	entries := make([]Entry, len(order))
+	for i, j := range order {
+		entries[i] = Entry{t.routes[j], t.status[j]}
+	}
+	return entries
+}
+
+// WriteTo writes one line per entry in the order of Entries
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	buffered := bufio.NewWriter(w)
+	var written int64
+	for _, entry := range t.Entries() {
+		n, err := fmt.Fprintln(buffered, entry)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, buffered.Flush()
+}
@@ -0,0 +1,194 @@
+package rib
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/demskie/subnetmath"
+)
+
+func testRoutes() []Route {
+	route := func(prefix, nextHop string, adminDistance, metric int) Route {
+		return Route{subnetmath.ParseNetworkCIDR(prefix), net.ParseIP(nextHop), metric, adminDistance}
+	}
+	return []Route{
+		route("192.0.2.0/24", "", 0, 0),
+		route("10.0.0.0/8", "192.0.2.2", 110, 20),
+		route("10.0.0.0/8", "192.0.2.1", 1, 0),
+		route("10.1.0.0/16", "198.18.0.1", 1, 0),
+		route("172.16.0.0/23", "192.0.2.1", 1, 0),
+		route("172.16.0.0/24", "192.0.2.2", 1, 0),
+		route("172.16.1.0/24", "192.0.2.2", 1, 0),
+		route("203.0.113.0/24", "10.9.9.9", 20, 0),
+		route("198.51.100.0/24", "203.0.113.5", 200, 0),
+		route("100.64.0.0/10", "100.64.0.1", 1, 0),
+		route("2001:db8::/64", "", 0, 0),
+		route("::/0", "2001:db8::1", 1, 0),
+	}
+}
+
+func TestTableEntries(t *testing.T) {
+	table, err := NewTable(testRoutes()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output strings.Builder
+	table.WriteTo(&output)
+	expected := strings.Join([]string{
+		"10.0.0.0/8 via 192.0.2.1 [1/0] active",
+		"10.0.0.0/8 via 192.0.2.2 [110/20] inactive",
+		"10.1.0.0/16 via 198.18.0.1 [1/0] unreachable",
+		"100.64.0.0/10 via 100.64.0.1 [1/0] unreachable",
+		"172.16.0.0/23 via 192.0.2.1 [1/0] shadowed",
+		"172.16.0.0/24 via 192.0.2.2 [1/0] active",
+		"172.16.1.0/24 via 192.0.2.2 [1/0] active",
+		"192.0.2.0/24 connected [0/0] active",
+		"198.51.100.0/24 via 203.0.113.5 [200/0] active",
+		"203.0.113.0/24 via 10.9.9.9 [20/0] active",
+		"::/0 via 2001:db8::1 [1/0] active",
+		"2001:db8::/64 connected [0/0] active",
+	}, "\n") + "\n"
+	if output.String() != expected {
+		t.Error(
+			"\n<<<input>>>\n", testRoutes(),
+			"\n<<<actual_output>>>\n", output.String(),
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	routes := testRoutes()
+	rand.New(rand.NewSource(1)).Shuffle(len(routes), func(i, j int) {
+		routes[i], routes[j] = routes[j], routes[i]
+	})
+	shuffled, _ := NewTable(routes...)
+	var shuffledOutput strings.Builder
+	shuffled.WriteTo(&shuffledOutput)
+	if shuffledOutput.String() != expected {
+		t.Error(
+			"\n<<<input>>>\n", routes,
+			"\n<<<actual_output>>>\n", shuffledOutput.String(),
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestTableLookup(t *testing.T) {
+	table, _ := NewTable(testRoutes()...)
+	for input, expected := range map[string]string{
+		"10.1.2.3":      "10.0.0.0/8 via 192.0.2.1 [1/0]",
+		"172.16.1.9":    "172.16.1.0/24 via 192.0.2.2 [1/0]",
+		"192.0.2.200":   "192.0.2.0/24 connected [0/0]",
+		"100.64.0.1":    "",
+		"8.8.8.8":       "",
+		"2001:db8::5":   "2001:db8::/64 connected [0/0]",
+		"2001:4860::88": "::/0 via 2001:db8::1 [1/0]",
+	} {
+		route, found := table.Lookup(net.ParseIP(input))
+		if found != (expected != "") || found && route.String() != expected {
+			t.Error(
+				"\n<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", route, found,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+	route, found := table.Best(subnetmath.ParseNetworkCIDR("10.0.0.0/8"))
+	if !found || route.String() != "10.0.0.0/8 via 192.0.2.1 [1/0]" {
+		t.Error(
+			"\n<<<input>>>\n", "10.0.0.0/8",
+			"\n<<<actual_output>>>\n", route, found,
+			"\n<<<expected_output>>>\n", "10.0.0.0/8 via 192.0.2.1 [1/0]",
+		)
+	}
+	if route, found := table.Best(subnetmath.ParseNetworkCIDR("10.1.0.0/16")); found {
+		t.Error(
+			"\n<<<input>>>\n", "10.1.0.0/16",
+			"\n<<<actual_output>>>\n", route,
+			"\n<<<expected_output>>>\n", "no active route",
+		)
+	}
+}
+
+func TestTableUnreachableMoreSpecific(t *testing.T) {
+	route := func(prefix, nextHop string) Route {
+		return Route{Prefix: subnetmath.ParseNetworkCIDR(prefix), NextHop: net.ParseIP(nextHop)}
+	}
+	// 192.0.2.1 resolves through the connected /24 since the /25 is unreachable
+	table, _ := NewTable(
+		route("192.0.2.0/24", ""),
+		route("192.0.2.0/25", "198.18.0.1"),
+		route("10.0.0.0/8", "192.0.2.1"),
+		route("172.16.0.0/12", "192.0.2.200"),
+	)
+	var output strings.Builder
+	table.WriteTo(&output)
+	expected := strings.Join([]string{
+		"10.0.0.0/8 via 192.0.2.1 [0/0] active",
+		"172.16.0.0/12 via 192.0.2.200 [0/0] active",
+		"192.0.2.0/24 connected [0/0] active",
+		"192.0.2.0/25 via 198.18.0.1 [0/0] unreachable",
+	}, "\n") + "\n"
+	if output.String() != expected {
+		t.Error(
+			"\n<<<actual_output>>>\n", output.String(),
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	if route, found := table.Lookup(net.ParseIP("192.0.2.1")); !found || route.String() != "192.0.2.0/24 connected [0/0]" {
+		t.Error(
+			"\n<<<input>>>\n", "192.0.2.1",
+			"\n<<<actual_output>>>\n", route, found,
+			"\n<<<expected_output>>>\n", "192.0.2.0/24 connected [0/0]",
+		)
+	}
+}
+
+func TestTableConcurrentReads(t *testing.T) {
+	table, _ := NewTable(testRoutes()...)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if route, found := table.Lookup(net.ParseIP("10.1.2.3")); !found || route.String() != "10.0.0.0/8 via 192.0.2.1 [1/0]" {
+				t.Error(
+					"\n<<<input>>>\n", "10.1.2.3",
+					"\n<<<actual_output>>>\n", route, found,
+					"\n<<<expected_output>>>\n", "10.0.0.0/8 via 192.0.2.1 [1/0]",
+				)
+			}
+			table.Entries()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTableAddErrors(t *testing.T) {
+	table, _ := NewTable()
+	for _, test := range []struct {
+		route    Route
+		expected error
+	}{
+		{Route{Prefix: nil}, subnetmath.ErrNilNetwork},
+		{Route{Prefix: &net.IPNet{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(8, 32)}}, subnetmath.ErrHostBitsSet},
+		{Route{Prefix: subnetmath.ParseNetworkCIDR("10.0.0.0/8"), NextHop: net.ParseIP("2001:db8::1")}, subnetmath.ErrMixedFamilies},
+		{Route{Prefix: subnetmath.ParseNetworkCIDR("10.0.0.0/8"), NextHop: net.IP{1, 2, 3}}, subnetmath.ErrInvalidAddress},
+	} {
+		if err := table.Add(test.route); !errors.Is(err, test.expected) {
+			t.Error(
+				"\n<<<input>>>\n", test.route,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+	if entries := table.Entries(); len(entries) != 0 {
+		t.Error(
+			"\n<<<actual_output>>>\n", entries,
+			"\n<<<expected_output>>>\n", "no entries",
+		)
+	}
+}
@@ -0,0 +1,35 @@
+package routerconf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderBIRD writes a filter with a prefix set for each family present since BIRD sets
+// hold a single type of prefix
+func renderBIRD(b *strings.Builder, prefixes []prefix, options Options) {
+	matched, otherwise := "accept", "reject"
+	if options.Deny {
+		matched, otherwise = otherwise, matched
+	}
+	fmt.Fprintf(b, "filter %s {\n", options.Name)
+	_, grouped := families(prefixes, options.Name)
+	for i, netType := range []string{"NET_IP4", "NET_IP6"} {
+		if len(grouped[i]) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\tif net.type = %s && net ~ [\n", netType)
+		for j, p := range grouped[i] {
+			fmt.Fprintf(b, "\t\t%v", p.network)
+			if !p.exact() {
+				fmt.Fprintf(b, "{%d,%d}", p.min, p.max)
+			}
+			if j < len(grouped[i])-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "\t] then %s;\n", matched)
+	}
+	fmt.Fprintf(b, "\t%s;\n}\n", otherwise)
+}
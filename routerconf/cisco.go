@@ -0,0 +1,40 @@
+package routerconf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/demskie/subnetmath"
+)
+
+// renderCisco writes an ip prefix-list for IPv4 and an ipv6 prefix-list for IPv6 with
+// sequence numbers counted separately for each
+func renderCisco(b *strings.Builder, prefixes []prefix, options Options) {
+	action := "permit"
+	if options.Deny {
+		action = "deny"
+	}
+	step := options.Step
+	if step == 0 {
+		step = 5
+	}
+	sequences := [2]int{options.Sequence, options.Sequence}
+	if options.Sequence == 0 {
+		sequences = [2]int{step, step}
+	}
+	for _, p := range prefixes {
+		keyword, i := "ip", 0
+		if p.family == subnetmath.IPv6 {
+			keyword, i = "ipv6", 1
+		}
+		fmt.Fprintf(b, "%s prefix-list %s seq %d %s %v", keyword, options.Name, sequences[i], action, p.network)
+		if options.Ge != 0 {
+			fmt.Fprintf(b, " ge %d", options.Ge)
+		}
+		if options.Le != 0 {
+			fmt.Fprintf(b, " le %d", options.Le)
+		}
+		b.WriteString("\n")
+		sequences[i] += step
+	}
+}
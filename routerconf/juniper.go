@@ -0,0 +1,36 @@
+package routerconf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderJuniper writes a prefix-list when the prefixes are permitted exactly and otherwise
+// a route-filter-list which can hold ranges of lengths and actions
+func renderJuniper(b *strings.Builder, prefixes []prefix, options Options) {
+	routeFilter := options.Deny || options.Ge != 0 || options.Le != 0
+	b.WriteString("policy-options {\n")
+	if routeFilter {
+		fmt.Fprintf(b, "    route-filter-list %s {\n", options.Name)
+	} else {
+		fmt.Fprintf(b, "    prefix-list %s {\n", options.Name)
+	}
+	for _, p := range prefixes {
+		fmt.Fprintf(b, "        %v", p.network)
+		if routeFilter {
+			switch {
+			case p.exact():
+				b.WriteString(" exact")
+			case p.min == p.ones:
+				fmt.Fprintf(b, " upto /%d", p.max)
+			default:
+				fmt.Fprintf(b, " prefix-length-range /%d-/%d", p.min, p.max)
+			}
+			if options.Deny {
+				b.WriteString(" reject")
+			}
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("    }\n}\n")
+}
@@ -0,0 +1,162 @@
+// Package routerconf renders lists of networks, such as the results of
+// subnetmath.FindUnusedSubnets, as router and firewall configuration.
+package routerconf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/demskie/subnetmath"
+)
+
+// Dialect identifies the configuration language that is rendered
+type Dialect int
+
+// supported dialects
+const (
+	// Cisco renders IOS ip and ipv6 prefix-lists with ge and le
+	Cisco Dialect = iota
+	// Juniper renders a policy-options prefix-list or, when lengths are given, a
+	// route-filter-list with prefix-length-range
+	Juniper
+	// BIRD renders a filter that matches the prefixes with the ~ operator
+	BIRD
+	// Nftables renders named interval sets of ipv4_addr and ipv6_addr
+	Nftables
+	// Ipset renders hash:net sets in the format read by ipset restore
+	Ipset
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case Cisco:
+		return "cisco"
+	case Juniper:
+		return "juniper"
+	case BIRD:
+		return "bird"
+	case Nftables:
+		return "nftables"
+	case Ipset:
+		return "ipset"
+	}
+	return "unknown"
+}
+
+// errors returned by Render
+var (
+	ErrInvalidName        = errors.New("routerconf: name must be non-empty without spaces or quotes")
+	ErrInvalidLengthRange = errors.New("routerconf: invalid prefix length range")
+	ErrUnsupported        = errors.New("routerconf: unsupported by dialect")
+)
+
+// Options configures the rendered configuration
+type Options struct {
+	// Name is the name of the prefix-list, filter or set
+	Name string
+	// Deny makes the prefixes denied rather than permitted where the dialect has actions
+	Deny bool
+	// Ge and Le extend each prefix to also match longer prefixes as with the ge and le of
+	// a Cisco prefix-list. Both zero matches the prefixes exactly.
+	Ge, Le int
+	// Sequence is the first Cisco sequence number and Step the increment, both default to 5
+	Sequence, Step int
+}
+
+// prefix is a validated network along with the range of lengths it matches
+type prefix struct {
+	network  *net.IPNet
+	family   subnetmath.Family
+	ones     int
+	bits     int
+	min, max int
+}
+
+func (p prefix) exact() bool {
+	return p.min == p.ones && p.max == p.ones
+}
+
+// Render writes the networks as configuration of the dialect. Networks are rendered in
+// the order given and must be valid, see subnetmath.ValidateNetwork.
+func Render(w io.Writer, dialect Dialect, networks []*net.IPNet, options Options) error {
+	if options.Name == "" || strings.ContainsAny(options.Name, " \t\r\n\"'{};") {
+		return ErrInvalidName
+	}
+	prefixes, err := prepare(networks, options)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	switch dialect {
+	case Cisco:
+		renderCisco(&b, prefixes, options)
+	case Juniper:
+		renderJuniper(&b, prefixes, options)
+	case BIRD:
+		renderBIRD(&b, prefixes, options)
+	case Nftables, Ipset:
+		if options.Ge != 0 || options.Le != 0 {
+			return fmt.Errorf("%w: %v sets match addresses rather than prefix lengths", ErrUnsupported, dialect)
+		}
+		if dialect == Nftables {
+			renderNftables(&b, prefixes, options)
+		} else {
+			renderIpset(&b, prefixes, options)
+		}
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupported, dialect)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// prepare validates the networks and the range of lengths that each of them matches
+func prepare(networks []*net.IPNet, options Options) ([]prefix, error) {
+	prefixes := make([]prefix, len(networks))
+	for i, network := range networks {
+		if err := subnetmath.ValidateNetwork(network); err != nil {
+			return nil, err
+		}
+		p := prefix{network: network, family: subnetmath.AddrFamily(network.IP)}
+		p.ones, _ = network.Mask.Size()
+		p.bits = 32
+		if p.family == subnetmath.IPv6 {
+			p.bits = 128
+		} else if len(network.Mask) == net.IPv6len {
+			p.ones -= 96
+		}
+		p.min, p.max = p.ones, p.ones
+		if options.Ge != 0 {
+			p.min, p.max = options.Ge, p.bits
+		}
+		if options.Le != 0 {
+			p.max = options.Le
+		}
+		if options.Ge != 0 && options.Ge <= p.ones || options.Le != 0 && options.Le <= p.ones ||
+			p.min > p.max || p.max > p.bits {
+			return nil, fmt.Errorf("%w: ge %d le %d for %v", ErrInvalidLengthRange, options.Ge, options.Le, network)
+		}
+		prefixes[i] = p
+	}
+	return prefixes, nil
+}
+
+// families returns the prefixes of each family present and the name of their list or set.
+// The names are only suffixed with the family when both families are present.
+func families(prefixes []prefix, name string) (names [2]string, grouped [2][]prefix) {
+	for _, p := range prefixes {
+		if p.family == subnetmath.IPv4 {
+			grouped[0] = append(grouped[0], p)
+		} else {
+			grouped[1] = append(grouped[1], p)
+		}
+	}
+	names = [2]string{name, name}
+	if len(grouped[0]) > 0 && len(grouped[1]) > 0 {
+		names = [2]string{name + "_v4", name + "_v6"}
+	}
+	return names, grouped
+}
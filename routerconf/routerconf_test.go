@@ -0,0 +1,93 @@
+package routerconf
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demskie/subnetmath"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func goldenNetworks() []*net.IPNet {
+	networks := subnetmath.FindUnusedSubnets(
+		subnetmath.ParseNetworkCIDR("192.168.0.0/22"),
+		subnetmath.ParseNetworkCIDR("192.168.1.0/24"),
+		subnetmath.ParseNetworkCIDR("192.168.2.32/30"),
+	)
+	return append(networks, subnetmath.ParseNetworkCIDR("2001:db8::/32"), subnetmath.ParseNetworkCIDR("::/0"))
+}
+
+func TestRenderGolden(t *testing.T) {
+	ipv4 := subnetmath.FindUnusedSubnets(subnetmath.ParseNetworkCIDR("10.0.0.0/16"), subnetmath.ParseNetworkCIDR("10.0.0.0/18"))
+	for _, test := range []struct {
+		golden   string
+		dialect  Dialect
+		networks []*net.IPNet
+		options  Options
+	}{
+		{"cisco.golden", Cisco, goldenNetworks(), Options{Name: "UNUSED"}},
+		{"cisco_range.golden", Cisco, ipv4, Options{Name: "CUSTOMERS", Deny: true, Ge: 24, Le: 28, Sequence: 10, Step: 10}},
+		{"juniper.golden", Juniper, goldenNetworks(), Options{Name: "unused"}},
+		{"juniper_range.golden", Juniper, ipv4, Options{Name: "customers", Le: 24}},
+		{"bird.golden", BIRD, goldenNetworks(), Options{Name: "unused"}},
+		{"bird_range.golden", BIRD, ipv4, Options{Name: "customers", Deny: true, Ge: 20}},
+		{"nftables.golden", Nftables, goldenNetworks(), Options{Name: "unused"}},
+		{"ipset.golden", Ipset, goldenNetworks(), Options{Name: "unused"}},
+	} {
+		var output bytes.Buffer
+		if err := Render(&output, test.dialect, test.networks, test.options); err != nil {
+			t.Errorf("%s: %v", test.golden, err)
+			continue
+		}
+		path := filepath.Join("testdata", test.golden)
+		if *update {
+			if err := os.WriteFile(path, output.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expected, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(output.Bytes(), expected) {
+			t.Error(
+				"\n<<<input>>>\n", test.golden, test.networks, err,
+				"\n<<<actual_output>>>\n", output.String(),
+				"\n<<<expected_output>>>\n", string(expected),
+			)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	networks := []*net.IPNet{subnetmath.ParseNetworkCIDR("10.0.0.0/16")}
+	for _, test := range []struct {
+		dialect  Dialect
+		networks []*net.IPNet
+		options  Options
+		expected error
+	}{
+		{Cisco, networks, Options{}, ErrInvalidName},
+		{Cisco, networks, Options{Name: "two words"}, ErrInvalidName},
+		{Cisco, networks, Options{Name: "A", Ge: 16}, ErrInvalidLengthRange},
+		{Cisco, networks, Options{Name: "A", Ge: 24, Le: 20}, ErrInvalidLengthRange},
+		{Cisco, networks, Options{Name: "A", Le: 33}, ErrInvalidLengthRange},
+		{Nftables, networks, Options{Name: "a", Le: 24}, ErrUnsupported},
+		{Dialect(42), networks, Options{Name: "a"}, ErrUnsupported},
+		{Cisco, []*net.IPNet{{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(8, 32)}}, Options{Name: "A"}, subnetmath.ErrHostBitsSet},
+		{Cisco, []*net.IPNet{nil}, Options{Name: "A"}, subnetmath.ErrNilNetwork},
+	} {
+		var output strings.Builder
+		if err := Render(&output, test.dialect, test.networks, test.options); !errors.Is(err, test.expected) || output.Len() != 0 {
+			t.Error(
+				"\n<<<input>>>\n", test.dialect, test.networks, test.options,
+				"\n<<<actual_output>>>\n", err, output.String(),
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
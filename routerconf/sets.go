@@ -0,0 +1,50 @@
+package routerconf
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/demskie/subnetmath"
+)
+
+// renderNftables writes a named interval set for each family present which belongs
+// within a table block. Overlapping networks are merged by nftables.
+func renderNftables(b *strings.Builder, prefixes []prefix, options Options) {
+	names, grouped := families(prefixes, options.Name)
+	for i, addrType := range []string{"ipv4_addr", "ipv6_addr"} {
+		if len(grouped[i]) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "set %s {\n\ttype %s\n\tflags interval\n\tauto-merge\n\telements = {\n", names[i], addrType)
+		for j, p := range grouped[i] {
+			fmt.Fprintf(b, "\t\t%v", p.network)
+			if j < len(grouped[i])-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\t}\n}\n")
+	}
+}
+
+// renderIpset writes a hash:net set for each family present. A /0 network is written as
+// its two /1 halves since hash:net sets can't hold a /0.
+func renderIpset(b *strings.Builder, prefixes []prefix, options Options) {
+	names, grouped := families(prefixes, options.Name)
+	for i, family := range []string{"inet", "inet6"} {
+		if len(grouped[i]) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "create %s hash:net family %s\n", names[i], family)
+		for _, p := range grouped[i] {
+			networks := []*net.IPNet{p.network}
+			if p.ones == 0 {
+				networks = subnetmath.FindInbetweenSubnets(p.network.IP, subnetmath.BroadcastAddr(p.network))
+			}
+			for _, network := range networks {
+				fmt.Fprintf(b, "add %s %v\n", names[i], network)
+			}
+		}
+	}
+}
@@ -1,11 +1,41 @@
+// Package subnetmath provides helpers for working with IPv4 and IPv6 networks.
+//
+// Functions accept addresses in either their 4 or 16 byte representation. Results follow
+// a canonical form so that they can be compared and round tripped reliably:
+//
+//   - a standalone net.IP is always 16 bytes long, IPv4 addresses use the IPv4-mapped
+//     form returned by net.IPv4 and net.ParseIP
+//   - a *net.IPNet always has an IP of the same length as its Mask, 4 bytes for IPv4
+//     and 16 bytes for IPv6, as returned by net.ParseCIDR
+//   - arithmetic that would leave the address family returns nil
 package subnetmath
 
 import (
+	"bytes"
 	"math"
 	"math/big"
 	"net"
 )
 
+// Family identifies the address family of an IP address
+type Family int
+
+// address families
+const (
+	IPv4 Family = 4
+	IPv6 Family = 6
+)
+
+func (f Family) String() string {
+	switch f {
+	case IPv4:
+		return "IPv4"
+	case IPv6:
+		return "IPv6"
+	}
+	return "unknown"
+}
+
 // commonly used bigint values
 var bigZero = big.NewInt(0)
 var bigOne = big.NewInt(1)
@@ -14,20 +44,23 @@ var bigTwo = big.NewInt(2)
 // ParseNetworkCIDR is a convienence function that will return either the *net.IPNet
 // or nil if the supplied cidr is invalid
 func ParseNetworkCIDR(cidr string) *net.IPNet {
-	addr, network, err := net.ParseCIDR(cidr)
-	if err == nil && network != nil && network.IP.Equal(addr) {
-		return network
-	}
-	return nil
+	network, _ := ParseNetworkCIDRStrict(cidr)
+	return network
 }
 
-// NetworksAreIdentical returns a bool with regards to the two networks being equal
+// NetworksAreIdentical returns a bool with regards to the two networks being equal.
+// Networks are compared by the number of host bits so that an IPv4 network and its
+// IPv4-mapped form are identical. Note that non-contiguous masks are only identical
+// if every byte is equal.
 func NetworksAreIdentical(first, second *net.IPNet) bool {
 	if first != second {
 		if first.IP.Equal(second.IP) {
-			firstSize, _ := first.Mask.Size()
-			secondSize, _ := second.Mask.Size()
-			if firstSize == secondSize {
+			firstSize, firstBits := first.Mask.Size()
+			secondSize, secondBits := second.Mask.Size()
+			if firstBits == 0 || secondBits == 0 {
+				return bytes.Equal(first.Mask, second.Mask)
+			}
+			if firstBits-firstSize == secondBits-secondSize {
 				return true
 			}
 		}
@@ -92,44 +125,74 @@ func DuplicateAddr(addr net.IP) net.IP {
 // SubnetZeroAddr returns the subnet zero address
 func SubnetZeroAddr(address net.IP, network *net.IPNet) net.IP {
 	if network != nil {
-		return address.Mask(network.Mask)
+		return address.Mask(network.Mask).To16()
 	}
 	return nil
 }
 
-// NextNetwork returns the next network of the same size
+// NextNetwork returns the next network of the same size or nil if the network is the
+// last one of its size in the address family or its mask isn't contiguous
 func NextNetwork(network *net.IPNet) *net.IPNet {
-	if network != nil {
-		nextNetwork := DuplicateNetwork(network)
+	if network != nil && MaskIsContiguous(network.Mask) {
 		networkInt := AddrToInt(network.IP)
 		networkInt.Add(networkInt, addressCount(network))
-		nextNetwork.IP = IntToAddr(networkInt)
-		return nextNetwork
+		nextAddr := IntToAddrFamily(networkInt, AddrFamily(network.IP))
+		if nextAddr != nil {
+			nextMask := make(net.IPMask, len(network.Mask))
+			copy(nextMask, network.Mask)
+			return canonicalNetwork(nextAddr, nextMask)
+		}
 	}
 	return nil
 }
 
-// BroadcastAddr returns the broadcast address
+// BroadcastAddr returns the broadcast address or nil if the mask isn't contiguous
 func BroadcastAddr(network *net.IPNet) net.IP {
-	if network != nil {
+	if network != nil && MaskIsContiguous(network.Mask) {
 		networkInt := AddrToInt(network.IP)
 		networkInt.Add(networkInt, addressCount(network))
 		networkInt.Sub(networkInt, bigOne)
-		return IntToAddr(networkInt)
+		return IntToAddrFamily(networkInt, AddrFamily(network.IP))
 	}
 	return nil
 }
 
-// NextAddr returns a new net.IP that is the next address
+// NextAddr returns a new net.IP that is the next address or nil if the address
+// is the last one in the address family
 func NextAddr(addr net.IP) net.IP {
 	addrInt := AddrToInt(addr)
 	addrInt.Add(addrInt, bigOne)
-	return IntToAddr(addrInt)
+	return IntToAddrFamily(addrInt, AddrFamily(addr))
 }
 
+// canonicalNetwork returns a *net.IPNet whose IP is the same length as the mask
+func canonicalNetwork(address net.IP, mask net.IPMask) *net.IPNet {
+	if len(mask) == net.IPv4len {
+		address = address.To4()
+	} else {
+		address = address.To16()
+	}
+	if address != nil {
+		return &net.IPNet{IP: address, Mask: mask}
+	}
+	return nil
+}
+
+// networkAddr returns the 4 byte form of IPv4 addresses and the 16 byte form otherwise
+func networkAddr(address net.IP) net.IP {
+	if v4addr := address.To4(); v4addr != nil {
+		return v4addr
+	}
+	return address.To16()
+}
+
+// addressCount returns nil for non-contiguous masks rather than treating them as a /0
 func addressCount(network *net.IPNet) *big.Int {
 	if network != nil {
 		ones, bits := network.Mask.Size()
+		if bits == 0 {
+			return nil
+		}
 		if bits <= 32 {
 			return new(big.Int).SetInt64(int64(math.Exp2(float64(bits - ones))))
 		}
@@ -150,99 +213,16 @@ func ShrinkNetwork(network *net.IPNet) *net.IPNet {
 	return nil
 }
 
-func sameAddrType(first, second net.IP) bool {
-	alpha, bravo := first.To4(), second.To4()
-	if alpha == nil && bravo == nil || alpha != nil && bravo != nil {
-		return true
-	}
-	return false
-}
-
-func maskBitLength(address net.IP) int {
-	if address.To4() != nil {
-		return 32
-	}
-	return 128
-}
-
 // FindInbetweenSubnets returns a slice of subnets given a range of IP addresses.
 // Note that the delimiter 'stop' is inclusive. In other words, it will be included in the result.
 func FindInbetweenSubnets(start, stop net.IP) []*net.IPNet {
-	if sameAddrType(start, stop) && AddressComesBefore(start, stop) {
-		var subnets []*net.IPNet
-		maskBits := maskBitLength(start)
-		current := DuplicateAddr(start)
-		stopInt := AddrToInt(stop)
-		for {
-			currentSubnet := &net.IPNet{IP: current}
-			for ones := 1; ones <= maskBits; ones++ {
-				currentSubnet.Mask = net.CIDRMask(ones, maskBits)
-				increment := addressCount(currentSubnet)
-				addressInt := AddrToInt(currentSubnet.IP)
-				addressInt.Add(addressInt, increment)
-				addressInt.Sub(addressInt, bigOne)
-				if addressInt.Cmp(stopInt) > 0 {
-					continue
-				}
-				if SubnetZeroAddr(currentSubnet.IP, currentSubnet).Equal(currentSubnet.IP) {
-					break
-				}
-			}
-			subnets = append(subnets, currentSubnet)
-			current = NextNetwork(currentSubnet).IP
-			if !current.Equal(stop) && AddressComesBefore(stop, current) ||
-				AddressComesBefore(current, start) {
-				break
-			}
-		}
-		return subnets
-	}
-	return nil
-}
-
-func isValidSubnet(network *net.IPNet) bool {
-	return SubnetZeroAddr(network.IP, network).Equal(network.IP)
-}
-
-func findNetworkIntersection(network *net.IPNet, otherNetworks ...*net.IPNet) *net.IPNet {
-	// OPTIMIZE: would having the subnets sorted allow for substantial shortcuts?
-	for _, otherNetwork := range otherNetworks {
-		if network.Contains(otherNetwork.IP) || otherNetwork.Contains(network.IP) {
-			return otherNetwork
-		}
-	}
-	return nil
+	return AppendInbetweenSubnets(nil, start, stop)
 }
 
-func findNetworkWithoutIntersection(currentNetwork *net.IPNet, otherNetworks ...*net.IPNet) {
-	// BUG: the logic here needs major rework, lots of performance left on the table
-	allZeroMask := make(net.IPMask, len(currentNetwork.Mask))
-	copy(currentNetwork.Mask, allZeroMask)
-	var lastIntersect *net.IPNet
-	for {
-		// shrink the network cidr by one
-		nextNetwork := ShrinkNetwork(currentNetwork)
-		if nextNetwork == nil {
-			// We trying to shrink a network size of one. This address is completely unusable.
-			// Try again with a new address offset from the lastIntersection
-			lastIntersectAddr := AddrToInt(lastIntersect.IP)
-			lastIntersectAddr.Add(lastIntersectAddr, addressCount(lastIntersect))
-			currentNetwork.IP = IntToAddr(lastIntersectAddr)
-			currentNetwork.Mask = allZeroMask
-		} else {
-			// search through all otherNetworks trying to find an intersection
-			lastIntersect = findNetworkIntersection(currentNetwork, otherNetworks...)
-			// return this network if there is no intersection and our network is valid
-			if lastIntersect == nil && isValidSubnet(currentNetwork) {
-				return
-			}
-		}
-	}
-}
-
-// NetworkContainsSubnet validates that the network is a valid supernet
+// NetworkContainsSubnet validates that the network is a valid supernet.
+// False is returned if either mask isn't contiguous.
 func NetworkContainsSubnet(network *net.IPNet, subnet *net.IPNet) bool {
-	if network != nil && subnet != nil {
+	if network != nil && subnet != nil && MaskIsContiguous(network.Mask) && MaskIsContiguous(subnet.Mask) {
 		supernetInt := AddrToInt(network.IP)
 		subnetInt := AddrToInt(subnet.IP)
 		if supernetInt.Cmp(subnetInt) <= 0 {
@@ -256,30 +236,51 @@ func NetworkContainsSubnet(network *net.IPNet, subnet *net.IPNet) bool {
 	return false
 }
 
-// FindUnusedSubnets returns a slice of unused subnets given the aggregate and sibling subnets
-func FindUnusedSubnets(aggregate *net.IPNet, subnets ...*net.IPNet) (unused []*net.IPNet) {
-	nextSubnet := DuplicateNetwork(aggregate)
-	if len(subnets) > 0 && findNetworkIntersection(aggregate, subnets...) != nil {
-		for {
-			findNetworkWithoutIntersection(nextSubnet, subnets...)
-			if NetworkContainsSubnet(aggregate, nextSubnet) {
-				unused = append(unused, nextSubnet)
-				nextSubnet = NextNetwork(nextSubnet)
-				continue
-			}
-			return unused
-		}
-	}
-	return append(unused, nextSubnet)
+// FindUnusedSubnets returns a slice of unused subnets given the aggregate and sibling subnets.
+// Nil is returned if the aggregate mask isn't contiguous and sibling subnets with masks
+// that aren't contiguous are ignored. See FindUnusedSubnetsStrict and ExpandArbitraryMask.
+func FindUnusedSubnets(aggregate *net.IPNet, subnets ...*net.IPNet) []*net.IPNet {
+	return AppendUnusedSubnets(nil, aggregate, subnets...)
 }
 
-// IntToAddr will return the net.IP of the big.Int represented address
+// IntToAddr will return the net.IP of the big.Int represented address. Values that fit
+// within 32 bits are returned as IPv4 addresses and larger values as IPv6 addresses.
+// Use IntToAddrFamily when the family is known so that addresses such as ::1 round trip.
 func IntToAddr(intAddress *big.Int) net.IP {
-	intBytes := intAddress.Bytes()
-	if len(intBytes) == 4 {
-		return net.IPv4(intBytes[0], intBytes[1], intBytes[2], intBytes[3])
+	if intAddress != nil && intAddress.BitLen() <= 32 {
+		return IntToAddrFamily(intAddress, IPv4)
 	}
-	return intBytes
+	return IntToAddrFamily(intAddress, IPv6)
+}
+
+// IntToAddrFamily will return the net.IP of the big.Int represented address within the
+// given family or nil if the value is negative or doesn't fit within the family
+func IntToAddrFamily(intAddress *big.Int, family Family) net.IP {
+	if intAddress == nil || intAddress.Sign() < 0 {
+		return nil
+	}
+	switch {
+	case family == IPv4 && intAddress.BitLen() <= 32:
+		address := make(net.IP, net.IPv6len)
+		copy(address, v4InV6Prefix)
+		intAddress.FillBytes(address[len(v4InV6Prefix):])
+		return address
+	case family == IPv6 && intAddress.BitLen() <= 128:
+		return intAddress.FillBytes(make(net.IP, net.IPv6len))
+	}
+	return nil
+}
+
+// AddrFamily returns the family of the address or zero if the address is invalid.
+// Note that IPv4-mapped IPv6 addresses are considered to be IPv4.
+func AddrFamily(address net.IP) Family {
+	if address.To4() != nil {
+		return IPv4
+	}
+	if len(address) == net.IPv6len {
+		return IPv6
+	}
+	return 0
 }
 
 // AddrToInt will return the *bit.Int of a given IPv6 address
@@ -294,23 +295,19 @@ func AddrToInt(address net.IP) *big.Int {
 // IPv4ClassfulNetwork eithers return the classful network given an IPv4 address or
 // returns nil if given a multicast address or IPv6 address
 func IPv4ClassfulNetwork(address net.IP) *net.IPNet {
-	if address.To4() != nil {
-		var newIP net.IP
+	if v4addr := address.To4(); v4addr != nil {
 		var newMask net.IPMask
 		switch {
-		case uint8(address[0]) < 128:
-			newIP = net.IPv4(uint8(address[0]), 0, 0, 0)
+		case v4addr[0] < 128:
 			newMask = net.IPv4Mask(255, 0, 0, 0)
-		case uint8(address[0]) < 192:
-			newIP = net.IPv4(uint8(address[0]), uint8(address[1]), 0, 0)
+		case v4addr[0] < 192:
 			newMask = net.IPv4Mask(255, 255, 0, 0)
-		case uint8(address[0]) < 224:
-			newIP = net.IPv4(uint8(address[0]), uint8(address[1]), uint8(address[2]), 0)
+		case v4addr[0] < 224:
 			newMask = net.IPv4Mask(255, 255, 255, 0)
 		default:
 			return nil
 		}
-		return &net.IPNet{IP: newIP, Mask: newMask}
+		return &net.IPNet{IP: v4addr.Mask(newMask), Mask: newMask}
 	}
 	return nil
 }
@@ -34,6 +34,27 @@ func TestNetworksAreIdentical(t *testing.T) {
 	}
 }
 
+func TestNetworksAreIdenticalMappedMask(t *testing.T) {
+	ipv4 := ParseNetworkCIDR("10.0.0.0/24")
+	for _, test := range []struct {
+		network  *net.IPNet
+		expected bool
+	}{
+		{&net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(120, 128)}, true},
+		{&net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(24, 32)}, true},
+		{&net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(24, 128)}, false},
+		{ParseNetworkCIDR("10.0.0.0/25"), false},
+	} {
+		if output := NetworksAreIdentical(ipv4, test.network); output != test.expected {
+			t.Error(
+				"\n<<<input>>>\n", ipv4, test.network.IP, test.network.Mask,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
+
 func TestNetworkComesBefore(t *testing.T) {
 	if !NetworkComesBefore(
 		ParseNetworkCIDR("192.168.0.0/23"),
@@ -120,6 +141,142 @@ func TestAddrToInt(t *testing.T) {
 	}
 }
 
+func TestIntToAddrFamily(t *testing.T) {
+	tests := []struct {
+		input  string
+		family Family
+	}{
+		{"0.0.0.0", IPv4},
+		{"0.0.0.5", IPv4},
+		{"0.0.1.0", IPv4},
+		{"192.168.1.2", IPv4},
+		{"255.255.255.255", IPv4},
+		{"::", IPv6},
+		{"::1", IPv6},
+		{"::ff:ffff", IPv6},
+		{"::1:0:0", IPv6},
+		{"::1:0:0:0", IPv6},
+		{"2001:db8::1", IPv6},
+		{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", IPv6},
+	}
+	for _, test := range tests {
+		input := net.ParseIP(test.input)
+		if AddrFamily(input) != test.family {
+			t.Error("\n", test.input, "should be of family", test.family)
+		}
+		actualOutput := IntToAddrFamily(AddrToInt(input), test.family)
+		if len(actualOutput) != net.IPv6len || !actualOutput.Equal(input) ||
+			AddrFamily(actualOutput) != test.family {
+			t.Error("\n",
+				"<<<input>>>\n", test.input,
+				"\n<<<actual_output>>>\n", []byte(actualOutput),
+				"\n<<<expected_output>>>\n", []byte(input),
+			)
+		}
+		if test.family == IPv4 && !IntToAddr(AddrToInt(input)).Equal(input) {
+			t.Error("\n", test.input, "should round trip through IntToAddr")
+		}
+	}
+	if IntToAddrFamily(new(big.Int).Lsh(bigOne, 32), IPv4) != nil {
+		t.Error("\n", "values beyond 32 bits are not IPv4 addresses")
+	}
+	if IntToAddrFamily(new(big.Int).Lsh(bigOne, 128), IPv6) != nil {
+		t.Error("\n", "values beyond 128 bits are not IPv6 addresses")
+	}
+	if IntToAddrFamily(big.NewInt(-1), IPv6) != nil {
+		t.Error("\n", "negative values are not addresses")
+	}
+}
+
+func TestCanonicalForms(t *testing.T) {
+	isCanonicalAddr := func(address net.IP) bool {
+		return len(address) == net.IPv6len
+	}
+	isCanonicalNetwork := func(network *net.IPNet) bool {
+		return network != nil && len(network.IP) == len(network.Mask)
+	}
+	for _, cidr := range []string{"192.168.0.0/23", "2001:db8::/64", "::/127", "0.0.0.0/31"} {
+		short := ParseNetworkCIDR(cidr)
+		long := &net.IPNet{IP: short.IP.To16(), Mask: short.Mask}
+		for _, network := range []*net.IPNet{short, long} {
+			if !isCanonicalAddr(SubnetZeroAddr(network.IP, network)) ||
+				!isCanonicalAddr(BroadcastAddr(network)) ||
+				!isCanonicalAddr(NextAddr(network.IP)) ||
+				!isCanonicalAddr(NewBuffer().SubnetZeroAddr(network.IP, network)) {
+				t.Error("\n", "non canonical address derived from", []byte(network.IP), network.Mask)
+			}
+			if !isCanonicalNetwork(NextNetwork(network)) {
+				t.Error("\n", "non canonical network derived from", []byte(network.IP), network.Mask)
+			}
+			if !NetworksAreIdentical(NextNetwork(network), NextNetwork(short)) {
+				t.Error("\n", "representation changed the result for", cidr)
+			}
+			unused := FindUnusedSubnets(network, short)
+			if len(unused) != 0 {
+				t.Error("\n", "expected no unused subnets for", cidr, "got", unused)
+			}
+		}
+		for _, subnet := range FindInbetweenSubnets(short.IP.To16(), BroadcastAddr(short)) {
+			if !isCanonicalNetwork(subnet) || !NetworksAreIdentical(subnet, short) {
+				t.Error("\n", "unexpected inbetween subnet", []byte(subnet.IP), subnet.Mask)
+			}
+		}
+	}
+	if NextAddr(net.ParseIP("255.255.255.255")) != nil || NextNetwork(ParseNetworkCIDR("255.255.255.0/24")) != nil {
+		t.Error("\n", "IPv4 arithmetic should not wrap into IPv6")
+	}
+	if !NextAddr(net.ParseIP("::")).Equal(net.ParseIP("::1")) {
+		t.Error("\n", "the address after :: should be ::1")
+	}
+	classful := IPv4ClassfulNetwork(net.ParseIP("192.168.5.1"))
+	if !isCanonicalNetwork(classful) || !NetworksAreIdentical(classful, ParseNetworkCIDR("192.168.5.0/24")) {
+		t.Error("\n", "unexpected classful network", classful)
+	}
+}
+
+func TestFindUnusedSubnetsLastNetwork(t *testing.T) {
+	aggregate := ParseNetworkCIDR("255.255.255.0/24")
+	subnets := []*net.IPNet{ParseNetworkCIDR("255.255.255.255/32")}
+	output := FindUnusedSubnets(aggregate, subnets...)
+	expected := FindInbetweenSubnets(net.ParseIP("255.255.255.0"), net.ParseIP("255.255.255.254"))
+	if !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error(
+			"\n<<<input>>>\n", "aggregate:", aggregate, "\n", subnets,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestFindUnusedSubnetsEndOfFamily(t *testing.T) {
+	aggregate := ParseNetworkCIDR("255.255.255.0/24")
+	subnets := []*net.IPNet{ParseNetworkCIDR("255.255.255.0/32")}
+	output := FindUnusedSubnets(aggregate, subnets...)
+	expected := FindInbetweenSubnets(net.ParseIP("255.255.255.1"), net.ParseIP("255.255.255.255"))
+	if !sliceOfSubnetsAreEqual(output, expected) {
+		t.Error(
+			"\n<<<input>>>\n", "aggregate:", aggregate, "\n", subnets,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestFindInbetweenSubnetsInvalidAddress(t *testing.T) {
+	stop := net.ParseIP("3030:3030:3030:3030:3030:3030:3030:3030")
+	for _, start := range []net.IP{{0x3f, 0x33, 0x30}, nil} {
+		output := FindInbetweenSubnets(start, stop)
+		buffered := NewBuffer().FindInbetweenSubnets(start, stop)
+		if output != nil || buffered != nil {
+			t.Error(
+				"\n<<<input>>>\n", []byte(start), stop,
+				"\n<<<actual_output>>>\n", output, buffered,
+				"\n<<<expected_output>>>\n", nil,
+			)
+		}
+	}
+}
+
 func TestFindInbetweenSubnets(t *testing.T) {
 	input := []net.IP{
 		net.ParseIP("192.168.1.2"),
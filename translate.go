@@ -0,0 +1,202 @@
+package subnetmath
+
+import (
+	"bytes"
+	"net"
+)
+
+// sixToFourPrefix is the 2002::/16 prefix from RFC 3056
+var sixToFourPrefix = &net.IPNet{
+	IP:   net.IP{0x20, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	Mask: net.CIDRMask(16, 128),
+}
+
+// WellKnownNAT64Prefix returns a new copy of the 64:ff9b::/96 prefix from RFC 6052
+func WellKnownNAT64Prefix() *net.IPNet {
+	return &net.IPNet{
+		IP:   net.IP{0, 0x64, 0xff, 0x9b, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		Mask: net.CIDRMask(96, 128),
+	}
+}
+
+func nat64PrefixLength(prefix *net.IPNet) int {
+	if isIPv6Network(prefix) {
+		ones, _ := prefix.Mask.Size()
+		switch ones {
+		case 32, 40, 48, 56, 64, 96:
+			return ones
+		}
+	}
+	return -1
+}
+
+// nat64BitPosition returns the position of the nth IPv4 bit within an IPv6 address
+// that embeds it after a prefix of the given length. Bits 64 through 71 are the RFC 6052
+// "u" octet and are skipped.
+func nat64BitPosition(prefixLength, n int) int {
+	position := prefixLength + n
+	if position >= 64 && prefixLength < 96 {
+		position += 8
+	}
+	return position
+}
+
+func sixToFourBitPosition(prefixLength, n int) int {
+	return prefixLength + n
+}
+
+func getBit(address []byte, position int) byte {
+	return address[position/8] >> uint(7-position%8) & 1
+}
+
+func setBit(address []byte, position int, value byte) {
+	if value != 0 {
+		address[position/8] |= 0x80 >> uint(position%8)
+	} else {
+		address[position/8] &^= 0x80 >> uint(position%8)
+	}
+}
+
+func embedIPv4(prefix *net.IPNet, prefixLength int, ipv4 net.IP, position func(int, int) int) net.IP {
+	v4addr := ipv4.To4()
+	if v4addr == nil {
+		return nil
+	}
+	embedded := make(net.IP, net.IPv6len)
+	copy(embedded, prefix.IP.Mask(prefix.Mask))
+	for i := 0; i < 32; i++ {
+		setBit(embedded, position(prefixLength, i), getBit(v4addr, i))
+	}
+	return embedded
+}
+
+func extractIPv4(prefix *net.IPNet, prefixLength int, ipv6 net.IP, position func(int, int) int) net.IP {
+	if ipv6.To4() != nil || len(ipv6) != net.IPv6len || !prefix.Contains(ipv6) {
+		return nil
+	}
+	extracted := make(net.IP, net.IPv4len)
+	for i := 0; i < 32; i++ {
+		setBit(extracted, i, getBit(ipv6, position(prefixLength, i)))
+	}
+	return net.IPv4(extracted[0], extracted[1], extracted[2], extracted[3])
+}
+
+func embedIPv4Network(prefix *net.IPNet, prefixLength int, network *net.IPNet,
+	position func(int, int) int) *net.IPNet {
+	if network == nil {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 32 {
+		return nil
+	}
+	embedded := embedIPv4(prefix, prefixLength, network.IP.Mask(network.Mask), position)
+	if embedded == nil {
+		return nil
+	}
+	length := prefixLength
+	if ones > 0 {
+		length = position(prefixLength, ones-1) + 1
+	}
+	return &net.IPNet{IP: embedded, Mask: net.CIDRMask(length, 128)}
+}
+
+func extractIPv4Network(prefix *net.IPNet, prefixLength int, network *net.IPNet,
+	position func(int, int) int) *net.IPNet {
+	if network == nil {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 128 || ones < prefixLength {
+		return nil
+	}
+	extracted := extractIPv4(prefix, prefixLength, network.IP, position)
+	if extracted == nil {
+		return nil
+	}
+	length := 0
+	for length < 32 && position(prefixLength, length) < ones {
+		length++
+	}
+	mask := net.CIDRMask(length, 32)
+	return &net.IPNet{IP: extracted.Mask(mask), Mask: mask}
+}
+
+// EmbedIPv4 returns the IPv6 address that embeds the IPv4 address within the RFC 6052
+// NAT64 prefix. Nil is returned if the prefix isn't a /32, /40, /48, /56, /64 or /96.
+func EmbedIPv4(prefix *net.IPNet, ipv4 net.IP) net.IP {
+	if prefixLength := nat64PrefixLength(prefix); prefixLength >= 0 {
+		return embedIPv4(prefix, prefixLength, ipv4, nat64BitPosition)
+	}
+	return nil
+}
+
+// ExtractIPv4 returns the IPv4 address embedded within the IPv6 address by EmbedIPv4.
+// Nil is returned if the IPv6 address isn't within the NAT64 prefix.
+func ExtractIPv4(prefix *net.IPNet, ipv6 net.IP) net.IP {
+	if prefixLength := nat64PrefixLength(prefix); prefixLength >= 0 {
+		return extractIPv4(prefix, prefixLength, ipv6, nat64BitPosition)
+	}
+	return nil
+}
+
+// EmbedIPv4Network returns the IPv6 network that embeds the IPv4 network within the NAT64 prefix
+func EmbedIPv4Network(prefix *net.IPNet, network *net.IPNet) *net.IPNet {
+	if prefixLength := nat64PrefixLength(prefix); prefixLength >= 0 {
+		return embedIPv4Network(prefix, prefixLength, network, nat64BitPosition)
+	}
+	return nil
+}
+
+// ExtractIPv4Network returns the IPv4 network embedded within the IPv6 network by EmbedIPv4Network.
+// Note that IPv6 networks which end partway through the "u" octet are widened to the preceding IPv4 bit.
+func ExtractIPv4Network(prefix *net.IPNet, network *net.IPNet) *net.IPNet {
+	if prefixLength := nat64PrefixLength(prefix); prefixLength >= 0 {
+		return extractIPv4Network(prefix, prefixLength, network, nat64BitPosition)
+	}
+	return nil
+}
+
+// SixToFourNetwork returns the 2002::/16 network that corresponds to the IPv4 network.
+// A single IPv4 address as a /32 becomes the /48 site prefix.
+func SixToFourNetwork(network *net.IPNet) *net.IPNet {
+	return embedIPv4Network(sixToFourPrefix, 16, network, sixToFourBitPosition)
+}
+
+// SixToFourIPv4 returns the IPv4 address embedded within a 6to4 address or nil if the
+// address isn't within 2002::/16
+func SixToFourIPv4(ipv6 net.IP) net.IP {
+	return extractIPv4(sixToFourPrefix, 16, ipv6, sixToFourBitPosition)
+}
+
+// IPv4MappedAddr returns the ::ffff:0:0/96 IPv4-mapped form of an IPv4 address
+func IPv4MappedAddr(ipv4 net.IP) net.IP {
+	if v4addr := ipv4.To4(); v4addr != nil {
+		return v4addr.To16()
+	}
+	return nil
+}
+
+// UnmapIPv4 returns the IPv4 address of an IPv4-mapped IPv6 address in its 16 byte form
+// or nil if the address isn't IPv4-mapped
+func UnmapIPv4(ipv6 net.IP) net.IP {
+	if len(ipv6) == net.IPv6len && bytes.Equal(ipv6[:12], v4InV6Prefix) {
+		return DuplicateAddr(ipv6[12:]).To16()
+	}
+	return nil
+}
+
+// IPv4MappedNetwork returns the ::ffff:0:0/96 IPv4-mapped form of an IPv4 network
+func IPv4MappedNetwork(network *net.IPNet) *net.IPNet {
+	if network == nil {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 32 {
+		return nil
+	}
+	return &net.IPNet{
+		IP:   IPv4MappedAddr(network.IP.Mask(network.Mask)),
+		Mask: net.CIDRMask(96+ones, 128),
+	}
+}
@@ -0,0 +1,136 @@
+package subnetmath
+
+import (
+	"net"
+	"testing"
+)
+
+// examples from RFC 6052 section 2.4
+var nat64Examples = []struct {
+	prefix   string
+	embedded string
+}{
+	{"2001:db8::/32", "2001:db8:c000:221::"},
+	{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+	{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+	{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+	{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+	{"2001:db8:122:344::/96", "2001:db8:122:344::192.0.2.33"},
+}
+
+func TestEmbedIPv4(t *testing.T) {
+	ipv4 := net.ParseIP("192.0.2.33")
+	for _, example := range nat64Examples {
+		prefix := ParseNetworkCIDR(example.prefix)
+		actualOutput := EmbedIPv4(prefix, ipv4)
+		expectedOutput := net.ParseIP(example.embedded)
+		if !actualOutput.Equal(expectedOutput) {
+			t.Error("\n",
+				"<<<input>>>\n", prefix, ipv4,
+				"\n<<<actual_output>>>\n", actualOutput,
+				"\n<<<expected_output>>>\n", expectedOutput,
+			)
+		}
+	}
+	if EmbedIPv4(ParseNetworkCIDR("2001:db8::/33"), ipv4) != nil {
+		t.Error("\n", "a /33 NAT64 prefix should be rejected")
+	}
+}
+
+func TestWellKnownNAT64Prefix(t *testing.T) {
+	prefix := WellKnownNAT64Prefix()
+	embedded := EmbedIPv4(prefix, net.ParseIP("192.0.2.33"))
+	prefix.IP[1] = 0
+	if !embedded.Equal(net.ParseIP("64:ff9b::192.0.2.33")) || WellKnownNAT64Prefix().String() != "64:ff9b::/96" {
+		t.Error("\n", "unexpected well-known prefix", embedded, WellKnownNAT64Prefix())
+	}
+}
+
+func TestExtractIPv4(t *testing.T) {
+	expectedOutput := net.ParseIP("192.0.2.33")
+	for _, example := range nat64Examples {
+		prefix := ParseNetworkCIDR(example.prefix)
+		actualOutput := ExtractIPv4(prefix, net.ParseIP(example.embedded))
+		if !actualOutput.Equal(expectedOutput) {
+			t.Error("\n",
+				"<<<input>>>\n", prefix, example.embedded,
+				"\n<<<actual_output>>>\n", actualOutput,
+				"\n<<<expected_output>>>\n", expectedOutput,
+			)
+		}
+	}
+}
+
+func TestEmbedIPv4Network(t *testing.T) {
+	tests := []struct {
+		prefix, input, expected string
+	}{
+		{"64:ff9b::/96", "192.0.2.0/24", "64:ff9b::c000:200/120"},
+		{"2001:db8::/32", "192.0.2.0/24", "2001:db8:c000:200::/56"},
+		{"2001:db8:100::/40", "192.0.2.0/24", "2001:db8:1c0:2::/64"},
+		{"2001:db8:100::/40", "192.0.2.128/25", "2001:db8:1c0:2:80::/73"},
+		{"2001:db8:100::/40", "10.0.0.0/8", "2001:db8:10a::/48"},
+	}
+	for _, test := range tests {
+		prefix, input := ParseNetworkCIDR(test.prefix), ParseNetworkCIDR(test.input)
+		actualOutput := EmbedIPv4Network(prefix, input)
+		expectedOutput := ParseNetworkCIDR(test.expected)
+		if !NetworksAreIdentical(actualOutput, expectedOutput) {
+			t.Error("\n",
+				"<<<input>>>\n", prefix, input,
+				"\n<<<actual_output>>>\n", actualOutput,
+				"\n<<<expected_output>>>\n", expectedOutput,
+			)
+		}
+		roundTrip := ExtractIPv4Network(prefix, actualOutput)
+		if !NetworksAreIdentical(roundTrip, input) {
+			t.Error("\n",
+				"<<<input>>>\n", prefix, actualOutput,
+				"\n<<<actual_output>>>\n", roundTrip,
+				"\n<<<expected_output>>>\n", input,
+			)
+		}
+	}
+}
+
+func TestSixToFour(t *testing.T) {
+	input := ParseNetworkCIDR("192.0.2.4/32")
+	actualOutput := SixToFourNetwork(input)
+	expectedOutput := ParseNetworkCIDR("2002:c000:204::/48")
+	if !NetworksAreIdentical(actualOutput, expectedOutput) {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+	extracted := SixToFourIPv4(net.ParseIP("2002:c000:204:1::1"))
+	if !extracted.Equal(net.ParseIP("192.0.2.4")) {
+		t.Error("\n",
+			"<<<input>>>\n", "2002:c000:204:1::1",
+			"\n<<<actual_output>>>\n", extracted,
+			"\n<<<expected_output>>>\n", "192.0.2.4",
+		)
+	}
+	if SixToFourIPv4(net.ParseIP("2001:db8::1")) != nil {
+		t.Error("\n", "2001:db8::1 is not a 6to4 address")
+	}
+}
+
+func TestIPv4Mapped(t *testing.T) {
+	mapped := IPv4MappedAddr(net.IP{192, 0, 2, 1})
+	if len(mapped) != net.IPv6len || !mapped.Equal(net.ParseIP("::ffff:192.0.2.1")) {
+		t.Error("\n", "unexpected mapped address", mapped)
+	}
+	unmapped := UnmapIPv4(mapped)
+	if len(unmapped) != net.IPv6len || AddrFamily(unmapped) != IPv4 || !unmapped.Equal(net.ParseIP("192.0.2.1")) {
+		t.Error("\n", "unexpected unmapped address", unmapped)
+	}
+	if UnmapIPv4(net.ParseIP("2001:db8::1")) != nil {
+		t.Error("\n", "2001:db8::1 is not an IPv4-mapped address")
+	}
+	network := IPv4MappedNetwork(ParseNetworkCIDR("192.0.2.0/24"))
+	if ones, _ := network.Mask.Size(); ones != 120 || !network.Contains(net.ParseIP("192.0.2.200")) {
+		t.Error("\n", "unexpected mapped network", network)
+	}
+}
@@ -0,0 +1,122 @@
+package subnetmath
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+)
+
+// SubnetNode is a network of an inventory along with the networks directly within it
+type SubnetNode struct {
+	Network  *net.IPNet
+	Children []*SubnetNode
+	// Free holds the subnets of Network that no child covers, see FindUnusedSubnets
+	Free []*net.IPNet
+}
+
+// BuildSubnetTree returns the hierarchy of the networks where each network is a child of
+// the smallest other network containing it. The roots and children are ordered by
+// NetworkComesBefore. Host bits are cleared, duplicate networks are only included once and
+// networks that aren't valid CIDR networks are ignored.
+func BuildSubnetTree(networks ...*net.IPNet) []*SubnetNode {
+	var sorted []*net.IPNet
+	for _, network := range networks {
+		if _, _, family := uint128Range(network); family != 0 && MaskIsContiguous(network.Mask) {
+			sorted = append(sorted, canonicalNetwork(network.IP.Mask(network.Mask), network.Mask))
+		}
+	}
+	slices.SortStableFunc(sorted, networkOrder)
+	var roots, stack []*SubnetNode
+	for i, network := range sorted {
+		if i > 0 && NetworksAreIdentical(network, sorted[i-1]) {
+			continue
+		}
+		node := &SubnetNode{Network: network}
+		for len(stack) > 0 && !containsSameFamily(stack[len(stack)-1].Network, network) {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+		stack = append(stack, node)
+	}
+	var annotate func(nodes []*SubnetNode)
+	annotate = func(nodes []*SubnetNode) {
+		for _, node := range nodes {
+			children := make([]*net.IPNet, len(node.Children))
+			for i, child := range node.Children {
+				children[i] = child.Network
+			}
+			node.Free = AppendUnusedSubnets(nil, node.Network, children...)
+			annotate(node.Children)
+		}
+	}
+	annotate(roots)
+	return roots
+}
+
+func containsSameFamily(network, subnet *net.IPNet) bool {
+	return AddrFamily(network.IP) == AddrFamily(subnet.IP) && NetworkContainsSubnet(network, subnet)
+}
+
+// UsedPercent returns the percentage of the addresses of the network covered by its children
+func (n *SubnetNode) UsedPercent() float64 {
+	return 100 - n.FreePercent()
+}
+
+// FreePercent returns the percentage of the addresses of the network that no child covers
+func (n *SubnetNode) FreePercent() float64 {
+	free := new(big.Int)
+	for _, subnet := range n.Free {
+		free.Add(free, addressCount(subnet))
+	}
+	percent, _ := new(big.Rat).SetFrac(free.Mul(free, big.NewInt(100)), addressCount(n.Network)).Float64()
+	return percent
+}
+
+// subnetNodeJSON is the JSON form of a SubnetNode
+type subnetNodeJSON struct {
+	Network     Network       `json:"network"`
+	UsedPercent float64       `json:"used_percent"`
+	FreePercent float64       `json:"free_percent"`
+	Free        []Network     `json:"free"`
+	Children    []*SubnetNode `json:"children,omitempty"`
+}
+
+// MarshalJSON returns the node with its percentages, free subnets and children
+func (n *SubnetNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subnetNodeJSON{
+		Network:     Network{n.Network},
+		UsedPercent: n.UsedPercent(),
+		FreePercent: n.FreePercent(),
+		Free:        WrapNetworks(append([]*net.IPNet{}, n.Free...)),
+		Children:    n.Children,
+	})
+}
+
+// WriteSubnetTree writes the trees as indented text with one network per line. Networks
+// with children are annotated with their used and free percentages.
+func WriteSubnetTree(w io.Writer, roots []*SubnetNode) error {
+	buffered := bufio.NewWriter(w)
+	var write func(nodes []*SubnetNode, depth int)
+	write = func(nodes []*SubnetNode, depth int) {
+		for _, node := range nodes {
+			buffered.WriteString(strings.Repeat("  ", depth) + node.Network.String())
+			if len(node.Children) > 0 {
+				fmt.Fprintf(buffered, " used %.2f%% free %.2f%%", node.UsedPercent(), node.FreePercent())
+			}
+			buffered.WriteString("\n")
+			write(node.Children, depth+1)
+		}
+	}
+	write(roots, 0)
+	return buffered.Flush()
+}
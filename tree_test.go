@@ -0,0 +1,67 @@
+package subnetmath
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestBuildSubnetTree(t *testing.T) {
+	input := parseNetworks(
+		"10.0.1.0/24", "10.0.0.0/16", "10.0.0.0/20", "10.0.0.0/24", "10.0.16.0/24",
+		"2001:db8::/32", "2001:db8:1::/48", "192.168.0.0/24", "10.0.0.0/24",
+	)
+	var output strings.Builder
+	err := WriteSubnetTree(&output, BuildSubnetTree(input...))
+	expected := strings.Join([]string{
+		"10.0.0.0/16 used 6.64% free 93.36%",
+		"  10.0.0.0/20 used 12.50% free 87.50%",
+		"    10.0.0.0/24",
+		"    10.0.1.0/24",
+		"  10.0.16.0/24",
+		"192.168.0.0/24",
+		"2001:db8::/32 used 0.00% free 100.00%",
+		"  2001:db8:1::/48",
+	}, "\n") + "\n"
+	if err != nil || output.String() != expected {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", output.String(), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestBuildSubnetTreeHostBits(t *testing.T) {
+	input := []*net.IPNet{
+		{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(24, 32)},
+		{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(24, 32)},
+		{IP: net.IP{10, 0, 0, 200}, Mask: net.CIDRMask(25, 32)},
+	}
+	var output strings.Builder
+	err := WriteSubnetTree(&output, BuildSubnetTree(input...))
+	expected := "10.0.0.0/24 used 50.00% free 50.00%\n  10.0.0.128/25\n"
+	if err != nil || output.String() != expected {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", output.String(), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
+
+func TestSubnetTreeJSON(t *testing.T) {
+	input := parseNetworks("10.0.0.0/22", "10.0.1.0/24")
+	output, err := json.Marshal(BuildSubnetTree(input...))
+	expected := `[{"network":"10.0.0.0/22","used_percent":25,"free_percent":75,` +
+		`"free":["10.0.0.0/24","10.0.2.0/23"],"children":[` +
+		`{"network":"10.0.1.0/24","used_percent":0,"free_percent":100,"free":["10.0.1.0/24"]}]}]`
+	if err != nil || string(output) != expected {
+		t.Error(
+			"\n<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", string(output), err,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+}
@@ -0,0 +1,204 @@
+package subnetmath
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"net"
+)
+
+// uint128 is a fixed width alternative to big.Int for allocation free address arithmetic
+type uint128 struct {
+	hi, lo uint64
+}
+
+var uint128Max = uint128{^uint64(0), ^uint64(0)}
+
+// uint128FromAddr returns the integer form of an address along with its family
+func uint128FromAddr(address net.IP) (uint128, Family) {
+	if v4addr := address.To4(); v4addr != nil {
+		return uint128{0, uint64(binary.BigEndian.Uint32(v4addr))}, IPv4
+	}
+	if len(address) == net.IPv6len {
+		return uint128{binary.BigEndian.Uint64(address[:8]), binary.BigEndian.Uint64(address[8:])}, IPv6
+	}
+	return uint128{}, 0
+}
+
+// uint128FromBytes returns the integer form of a 4 or 16 byte slice without regard to family
+func uint128FromBytes(b []byte) uint128 {
+	if len(b) == net.IPv4len {
+		return uint128{0, uint64(binary.BigEndian.Uint32(b))}
+	}
+	return uint128{binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])}
+}
+
+// uint128Range returns the first and last address of a network along with its family.
+// The family is zero if the network is nil or its mask isn't a canonical CIDR mask.
+func uint128Range(network *net.IPNet) (first, last uint128, family Family) {
+	if network == nil {
+		return first, last, 0
+	}
+	ones, maskBits := network.Mask.Size()
+	first, family = uint128FromAddr(network.IP)
+	if family == 0 || maskBits == 0 || maskBits != familyBits(family) && maskBits != 128 {
+		return first, last, 0
+	}
+	hostBits := maskBits - ones
+	if hostBits > familyBits(family) {
+		return first, last, 0
+	}
+	hostMask := uint128Ones(hostBits)
+	first = first.and(hostMask.not())
+	return first, first.or(hostMask), family
+}
+
+func familyBits(family Family) int {
+	if family == IPv4 {
+		return 32
+	}
+	return 128
+}
+
+// uint128Ones returns an integer with the lowest n bits set
+func uint128Ones(n int) uint128 {
+	switch {
+	case n <= 0:
+		return uint128{}
+	case n < 64:
+		return uint128{0, 1<<uint(n) - 1}
+	case n < 128:
+		return uint128{1<<uint(n-64) - 1, ^uint64(0)}
+	}
+	return uint128Max
+}
+
+// uint128Pow2 returns 2^n for n below 128
+func uint128Pow2(n int) uint128 {
+	if n < 64 {
+		return uint128{0, 1 << uint(n)}
+	}
+	return uint128{1 << uint(n-64), 0}
+}
+
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi < v.hi:
+		return -1
+	case u.hi > v.hi:
+		return 1
+	case u.lo < v.lo:
+		return -1
+	case u.lo > v.lo:
+		return 1
+	}
+	return 0
+}
+
+func (u uint128) and(v uint128) uint128 {
+	return uint128{u.hi & v.hi, u.lo & v.lo}
+}
+
+func (u uint128) or(v uint128) uint128 {
+	return uint128{u.hi | v.hi, u.lo | v.lo}
+}
+
+func (u uint128) xor(v uint128) uint128 {
+	return uint128{u.hi ^ v.hi, u.lo ^ v.lo}
+}
+
+func (u uint128) not() uint128 {
+	return uint128{^u.hi, ^u.lo}
+}
+
+// add returns the sum and whether it overflowed
+func (u uint128) add(v uint128) (uint128, bool) {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, carry := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi, lo}, carry != 0
+}
+
+func (u uint128) sub(v uint128) uint128 {
+	lo, borrow := bits.Sub64(u.lo, v.lo, 0)
+	hi, _ := bits.Sub64(u.hi, v.hi, borrow)
+	return uint128{hi, lo}
+}
+
+// lsh returns the integer shifted left by n bits, discarding the bits shifted out
+func (u uint128) lsh(n int) uint128 {
+	switch {
+	case n <= 0:
+		return u
+	case n < 64:
+		return uint128{u.hi<<uint(n) | u.lo>>uint(64-n), u.lo << uint(n)}
+	case n < 128:
+		return uint128{u.lo << uint(n-64), 0}
+	}
+	return uint128{}
+}
+
+// bit returns the nth bit counting from the least significant bit at zero
+func (u uint128) bit(n int) int {
+	if n >= 64 {
+		return int(u.hi>>uint(n-64)) & 1
+	}
+	return int(u.lo>>uint(n)) & 1
+}
+
+func (u uint128) bitLen() int {
+	if u.hi != 0 {
+		return 64 + bits.Len64(u.hi)
+	}
+	return bits.Len64(u.lo)
+}
+
+func (u uint128) trailingZeros() int {
+	if u.lo != 0 {
+		return bits.TrailingZeros64(u.lo)
+	}
+	if u.hi != 0 {
+		return 64 + bits.TrailingZeros64(u.hi)
+	}
+	return 128
+}
+
+// putAddr writes the address into dst which must be 4 bytes for IPv4 or 16 bytes for IPv6
+func (u uint128) putAddr(dst []byte) {
+	if len(dst) == net.IPv4len {
+		binary.BigEndian.PutUint32(dst, uint32(u.lo))
+		return
+	}
+	binary.BigEndian.PutUint64(dst[:8], u.hi)
+	binary.BigEndian.PutUint64(dst[8:], u.lo)
+}
+
+// decomposeRange calls emit with the largest aligned subnets that cover the inclusive
+// range from first to last. Subnets are never shorter than minOnes. Decomposition stops
+// early and false is returned if emit returns false.
+func decomposeRange(first, last uint128, family Family, minOnes int, emit func(uint128, int) bool) bool {
+	maskBits := familyBits(family)
+	current := first
+	for current.cmp(last) <= 0 {
+		hostBits := 128
+		if remaining, overflow := last.sub(current).add(uint128{0, 1}); !overflow {
+			hostBits = remaining.bitLen() - 1
+		}
+		if alignment := current.trailingZeros(); alignment < hostBits {
+			hostBits = alignment
+		}
+		if hostBits > maskBits-minOnes {
+			hostBits = maskBits - minOnes
+		}
+		if !emit(current, maskBits-hostBits) {
+			return false
+		}
+		if hostBits == 128 {
+			break
+		}
+		next, overflow := current.add(uint128Pow2(hostBits))
+		if overflow {
+			break
+		}
+		current = next
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package subnetmath
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// errors wrapped by *ValidationError
+var (
+	ErrNilNetwork         = errors.New("nil network")
+	ErrInvalidAddress     = errors.New("invalid address")
+	ErrInvalidCIDR        = errors.New("invalid CIDR")
+	ErrHostBitsSet        = errors.New("host bits are set")
+	ErrNonContiguousMask  = errors.New("mask is not contiguous")
+	ErrMismatchedMaskSize = errors.New("mask size does not match the address")
+	ErrMixedFamilies      = errors.New("mixed address families")
+	ErrReversedRange      = errors.New("stop comes before start")
+)
+
+// ValidationError describes which argument failed validation and why
+type ValidationError struct {
+	Arg string
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "subnetmath: invalid " + e.Arg + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error so that errors.Is can match the sentinel errors
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ParseNetworkCIDRStrict returns the *net.IPNet of the supplied cidr or a *ValidationError
+// describing why it is invalid. Unlike net.ParseCIDR a cidr with host bits set is rejected.
+func ParseNetworkCIDRStrict(cidr string) (*net.IPNet, error) {
+	addr, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, &ValidationError{"cidr", fmt.Errorf("%w: %q", ErrInvalidCIDR, cidr)}
+	}
+	if !network.IP.Equal(addr) {
+		return nil, &ValidationError{"cidr", fmt.Errorf("%w: %q is within %v", ErrHostBitsSet, cidr, network)}
+	}
+	return network, nil
+}
+
+// ValidateNetwork returns a *ValidationError if the network is nil, has an invalid address,
+// a mask that isn't contiguous or doesn't suit the address, or has host bits set
+func ValidateNetwork(network *net.IPNet) error {
+	return validateNetwork("network", network)
+}
+
+func validateNetwork(arg string, network *net.IPNet) error {
+	if network == nil {
+		return &ValidationError{arg, ErrNilNetwork}
+	}
+	family := AddrFamily(network.IP)
+	if family == 0 {
+		return &ValidationError{arg, fmt.Errorf("%w: %v", ErrInvalidAddress, []byte(network.IP))}
+	}
+	switch {
+	case len(network.Mask) == net.IPv6len && family == IPv4 && !allFF(network.Mask[:12]):
+		fallthrough
+	case len(network.Mask) == net.IPv4len && family == IPv6:
+		fallthrough
+	case len(network.Mask) != net.IPv4len && len(network.Mask) != net.IPv6len:
+		return &ValidationError{arg, fmt.Errorf("%w: %v with mask %v", ErrMismatchedMaskSize, network.IP, network.Mask)}
+	}
+	if !MaskIsContiguous(network.Mask) {
+		return &ValidationError{arg, fmt.Errorf("%w: %v", ErrNonContiguousMask, net.IP(network.Mask))}
+	}
+	if !network.IP.Equal(network.IP.Mask(network.Mask)) {
+		return &ValidationError{arg, fmt.Errorf("%w: %v", ErrHostBitsSet, network)}
+	}
+	return nil
+}
+
+func validateAddr(arg string, address net.IP) error {
+	if AddrFamily(address) == 0 {
+		return &ValidationError{arg, fmt.Errorf("%w: %v", ErrInvalidAddress, []byte(address))}
+	}
+	return nil
+}
+
+// validateInbetween validates the arguments of FindInbetweenSubnets
+func validateInbetween(start, stop net.IP) error {
+	if err := validateAddr("start", start); err != nil {
+		return err
+	}
+	if err := validateAddr("stop", stop); err != nil {
+		return err
+	}
+	if startFamily, stopFamily := AddrFamily(start), AddrFamily(stop); startFamily != stopFamily {
+		return &ValidationError{"stop", fmt.Errorf("%w: %v stop with %v start", ErrMixedFamilies, stopFamily, startFamily)}
+	}
+	if AddressComesBefore(stop, start) {
+		return &ValidationError{"stop", fmt.Errorf("%w: %v is before %v", ErrReversedRange, stop, start)}
+	}
+	return nil
+}
+
+// validateUnused validates the arguments of FindUnusedSubnets
+func validateUnused(aggregate *net.IPNet, subnets []*net.IPNet) error {
+	if err := validateNetwork("aggregate", aggregate); err != nil {
+		return err
+	}
+	family := AddrFamily(aggregate.IP)
+	for i, subnet := range subnets {
+		arg := fmt.Sprintf("subnets[%d]", i)
+		if err := validateNetwork(arg, subnet); err != nil {
+			return err
+		}
+		if subnetFamily := AddrFamily(subnet.IP); subnetFamily != family {
+			return &ValidationError{arg, fmt.Errorf("%w: %v subnet %v within %v aggregate %v",
+				ErrMixedFamilies, subnetFamily, subnet, family, aggregate)}
+		}
+	}
+	return nil
+}
+
+// FindInbetweenSubnetsStrict is FindInbetweenSubnets that returns a *ValidationError
+// rather than nil when given invalid addresses, addresses of different families or a
+// stop that comes before start. Unlike FindInbetweenSubnets the single host network is
+// returned when start and stop are equal.
+func FindInbetweenSubnetsStrict(start, stop net.IP) ([]*net.IPNet, error) {
+	if err := validateInbetween(start, stop); err != nil {
+		return nil, err
+	}
+	var subnets []*net.IPNet
+	walkInbetweenSubnetsStrict(start, stop, func(addr uint128, ones int, family Family) bool {
+		subnets = appendNetworkPointer(subnets, addr, ones, family)
+		return true
+	})
+	return subnets, nil
+}
+
+// walkInbetweenSubnetsStrict is walkInbetweenSubnets that emits the single host network
+// when start and stop are equal
+func walkInbetweenSubnetsStrict(start, stop net.IP, emit subnetEmitter) bool {
+	if addr, family := uint128FromAddr(start); family != 0 && start.Equal(stop) {
+		return emit(addr, familyBits(family), family)
+	}
+	return walkInbetweenSubnets(start, stop, emit)
+}
+
+// FindUnusedSubnetsStrict is FindUnusedSubnets that returns a *ValidationError rather
+// than silently ignoring nil networks, networks with host bits set or masks that aren't
+// contiguous, and sibling subnets that are a different family than the aggregate
+func FindUnusedSubnetsStrict(aggregate *net.IPNet, subnets ...*net.IPNet) ([]*net.IPNet, error) {
+	if err := validateUnused(aggregate, subnets); err != nil {
+		return nil, err
+	}
+	return AppendUnusedSubnets(nil, aggregate, subnets...), nil
+}
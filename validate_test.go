@@ -0,0 +1,113 @@
+package subnetmath
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestParseNetworkCIDRStrict(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{"192.168.0.0/23", nil},
+		{"2001:db8::/32", nil},
+		{"192.168.1.0/23", ErrHostBitsSet},
+		{"192.168.0.0/33", ErrInvalidCIDR},
+		{"not a cidr", ErrInvalidCIDR},
+	}
+	for _, test := range tests {
+		network, err := ParseNetworkCIDRStrict(test.input)
+		if !errors.Is(err, test.expected) || (err == nil) != (network != nil) {
+			t.Error("\n",
+				"<<<input>>>\n", test.input,
+				"\n<<<actual_output>>>\n", network, err,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
+
+func TestValidateNetwork(t *testing.T) {
+	tests := []struct {
+		input    *net.IPNet
+		expected error
+	}{
+		{ParseNetworkCIDR("192.168.0.0/23"), nil},
+		{&net.IPNet{IP: net.ParseIP("192.168.0.0"), Mask: net.CIDRMask(23, 32)}, nil},
+		{nil, ErrNilNetwork},
+		{&net.IPNet{IP: net.IP{1, 2, 3}, Mask: net.CIDRMask(8, 32)}, ErrInvalidAddress},
+		{&net.IPNet{IP: net.ParseIP("2001:db8::"), Mask: net.CIDRMask(8, 32)}, ErrMismatchedMaskSize},
+		{&net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPv4Mask(255, 0, 255, 0)}, ErrNonContiguousMask},
+		{&net.IPNet{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(8, 32)}, ErrHostBitsSet},
+	}
+	for _, test := range tests {
+		err := ValidateNetwork(test.input)
+		var validationErr *ValidationError
+		if !errors.Is(err, test.expected) || err != nil && !errors.As(err, &validationErr) {
+			t.Error("\n",
+				"<<<input>>>\n", test.input,
+				"\n<<<actual_output>>>\n", err,
+				"\n<<<expected_output>>>\n", test.expected,
+			)
+		}
+	}
+}
+
+func TestFindInbetweenSubnetsStrict(t *testing.T) {
+	start, stop := net.ParseIP("192.168.1.2"), net.ParseIP("192.168.2.2")
+	output, err := FindInbetweenSubnetsStrict(start, stop)
+	if err != nil || !sliceOfSubnetsAreEqual(output, FindInbetweenSubnets(start, stop)) {
+		t.Error("\n", "unexpected result", output, err)
+	}
+	if _, err = FindInbetweenSubnetsStrict(start, net.ParseIP("2001:db8::")); !errors.Is(err, ErrMixedFamilies) {
+		t.Error("\n", "expected ErrMixedFamilies but got", err)
+	}
+	if _, err = FindInbetweenSubnetsStrict(stop, start); !errors.Is(err, ErrReversedRange) {
+		t.Error("\n", "expected ErrReversedRange but got", err)
+	}
+	if _, err = FindInbetweenSubnetsStrict(nil, stop); !errors.Is(err, ErrInvalidAddress) {
+		t.Error("\n", "expected ErrInvalidAddress but got", err)
+	}
+	for _, host := range []string{"192.168.1.2", "2001:db8::1", "::ffff:10.0.0.1"} {
+		address := net.ParseIP(host)
+		output, err := FindInbetweenSubnetsStrict(address, address)
+		ctxOutput, ctxErr := FindInbetweenSubnetsCtx(context.Background(), Limits{}, address, address)
+		expected := []*net.IPNet{ParseNetworkCIDR(address.String() + "/32")}
+		if address.To4() == nil {
+			expected = []*net.IPNet{ParseNetworkCIDR(address.String() + "/128")}
+		}
+		if err != nil || ctxErr != nil || !sliceOfSubnetsAreEqual(output, expected) || !sliceOfSubnetsAreEqual(ctxOutput, expected) {
+			t.Error(
+				"\n<<<input>>>\n", address, address,
+				"\n<<<actual_output>>>\n", output, err, ctxOutput, ctxErr,
+				"\n<<<expected_output>>>\n", expected,
+			)
+		}
+	}
+}
+
+func TestFindUnusedSubnetsStrict(t *testing.T) {
+	aggregate := ParseNetworkCIDR("192.168.0.0/22")
+	subnets := []*net.IPNet{
+		ParseNetworkCIDR("192.168.1.0/24"),
+		ParseNetworkCIDR("192.168.2.32/30"),
+	}
+	output, err := FindUnusedSubnetsStrict(aggregate, subnets...)
+	if err != nil || !sliceOfSubnetsAreEqual(output, FindUnusedSubnets(aggregate, subnets...)) {
+		t.Error("\n", "unexpected result", output, err)
+	}
+	_, err = FindUnusedSubnetsStrict(aggregate, subnets[0], ParseNetworkCIDR("2001:db8::/64"))
+	var validationErr *ValidationError
+	if !errors.Is(err, ErrMixedFamilies) || !errors.As(err, &validationErr) || validationErr.Arg != "subnets[1]" {
+		t.Error("\n", "expected ErrMixedFamilies for subnets[1] but got", err)
+	}
+	if _, err = FindUnusedSubnetsStrict(nil, subnets...); !errors.Is(err, ErrNilNetwork) {
+		t.Error("\n", "expected ErrNilNetwork but got", err)
+	}
+	if _, err = FindUnusedSubnetsStrict(aggregate, subnets[0], nil); !errors.Is(err, ErrNilNetwork) {
+		t.Error("\n", "expected ErrNilNetwork but got", err)
+	}
+}
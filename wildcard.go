@@ -0,0 +1,83 @@
+package subnetmath
+
+import (
+	"net"
+)
+
+// ACLEntry is an address and wildcard pair as used by router access lists.
+// Bits that are set in the wildcard are ignored when matching.
+type ACLEntry struct {
+	Address  net.IP
+	Wildcard net.IPMask
+}
+
+// String returns the entry in the form "10.0.0.0 0.0.0.255"
+func (entry ACLEntry) String() string {
+	return entry.Address.String() + " " + net.IP(entry.Wildcard).String()
+}
+
+// Contains reports whether the address is matched by the entry
+func (entry ACLEntry) Contains(address net.IP) bool {
+	network := FromWildcard(entry.Address, entry.Wildcard)
+	if network != nil {
+		return network.Contains(address)
+	}
+	return false
+}
+
+// WildcardMask returns the inverse of the network mask or nil if the network is nil
+func WildcardMask(network *net.IPNet) net.IPMask {
+	if network != nil {
+		return invertMask(network.Mask)
+	}
+	return nil
+}
+
+// FromWildcard returns the *net.IPNet matched by the address and wildcard or nil if
+// they are of different lengths. Note that the wildcard doesn't need to be contiguous.
+func FromWildcard(address net.IP, wildcard net.IPMask) *net.IPNet {
+	if len(wildcard) == net.IPv4len {
+		address = address.To4()
+	} else if len(wildcard) == net.IPv6len {
+		address = address.To16()
+	} else {
+		return nil
+	}
+	if address == nil {
+		return nil
+	}
+	mask := invertMask(wildcard)
+	return &net.IPNet{IP: address.Mask(mask), Mask: mask}
+}
+
+// RangeToACL returns ACL entries matching the range of IP addresses.
+// Note that the delimiter 'stop' is inclusive. The entries are the CIDR decomposition of
+// FindInbetweenSubnets, which is the fewest entries with contiguous wildcards. Entries
+// with non-contiguous wildcards can sometimes match the range with fewer entries, such as
+// 10.0.0.1 through 10.0.0.6, but they aren't produced.
+func RangeToACL(start, stop net.IP) []ACLEntry {
+	subnets := FindInbetweenSubnets(start, stop)
+	if subnets == nil {
+		return nil
+	}
+	entries := make([]ACLEntry, 0, len(subnets))
+	for _, subnet := range subnets {
+		address := subnet.IP.To4()
+		if len(subnet.Mask) == net.IPv6len {
+			address = subnet.IP.To16()
+		}
+		entries = append(entries, ACLEntry{
+			Address:  DuplicateAddr(address),
+			Wildcard: WildcardMask(subnet),
+		})
+	}
+	return entries
+}
+
+func invertMask(mask net.IPMask) net.IPMask {
+	inverted := make(net.IPMask, len(mask))
+	for i := range mask {
+		inverted[i] = ^mask[i]
+	}
+	return inverted
+}
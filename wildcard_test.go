@@ -0,0 +1,90 @@
+package subnetmath
+
+import (
+	"net"
+	"testing"
+)
+
+func TestWildcardMask(t *testing.T) {
+	input := ParseNetworkCIDR("10.0.0.0/24")
+	actualOutput := net.IP(WildcardMask(input)).String()
+	expectedOutput := "0.0.0.255"
+	if actualOutput != expectedOutput {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+}
+
+func TestFromWildcard(t *testing.T) {
+	input := ACLEntry{net.ParseIP("10.0.0.7"), net.IPv4Mask(0, 0, 0, 255)}
+	actualOutput := FromWildcard(input.Address, input.Wildcard)
+	expectedOutput := ParseNetworkCIDR("10.0.0.0/24")
+	if !NetworksAreIdentical(actualOutput, expectedOutput) {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", actualOutput,
+			"\n<<<expected_output>>>\n", expectedOutput,
+		)
+	}
+	input = ACLEntry{net.ParseIP("10.0.0.0"), net.IPv4Mask(0, 0, 2, 255)}
+	network := FromWildcard(input.Address, input.Wildcard)
+	for _, addr := range []string{"10.0.0.1", "10.0.2.254"} {
+		if !network.Contains(net.ParseIP(addr)) {
+			t.Error("\n", input, "should contain", addr)
+		}
+	}
+	if network.Contains(net.ParseIP("10.0.1.1")) {
+		t.Error("\n", input, "should not contain 10.0.1.1")
+	}
+}
+
+func TestRangeToACL(t *testing.T) {
+	input := []net.IP{
+		net.ParseIP("192.168.1.2"),
+		net.ParseIP("192.168.2.2"),
+	}
+	output := RangeToACL(input[0], input[1])
+	expected := []string{
+		"192.168.1.2 0.0.0.1",
+		"192.168.1.4 0.0.0.3",
+		"192.168.1.8 0.0.0.7",
+		"192.168.1.16 0.0.0.15",
+		"192.168.1.32 0.0.0.31",
+		"192.168.1.64 0.0.0.63",
+		"192.168.1.128 0.0.0.127",
+		"192.168.2.0 0.0.0.1",
+		"192.168.2.2 0.0.0.0",
+	}
+	if len(output) != len(expected) {
+		t.Fatal("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", expected,
+		)
+	}
+	for i := range output {
+		if output[i].String() != expected[i] {
+			t.Error("\n",
+				"<<<input>>>\n", input,
+				"\n<<<actual_output>>>\n", output,
+				"\n<<<expected_output>>>\n", expected,
+			)
+			break
+		}
+	}
+	input = []net.IP{
+		net.ParseIP("10.0.0.0"),
+		net.ParseIP("10.0.0.255"),
+	}
+	output = RangeToACL(input[0], input[1])
+	if len(output) != 1 || output[0].String() != "10.0.0.0 0.0.0.255" {
+		t.Error("\n",
+			"<<<input>>>\n", input,
+			"\n<<<actual_output>>>\n", output,
+			"\n<<<expected_output>>>\n", "[10.0.0.0 0.0.0.255]",
+		)
+	}
+}